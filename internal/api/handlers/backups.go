@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+const (
+	// Default directory holding backups when BACKUP_DIR is not set
+	defaultBackupDir = "backups"
+	// File listing every backup in the backup directory
+	backupManifest = "manifest.json"
+	// File holding the git data of a backup
+	backupArchive = "repository.tar.gz"
+	// GitHub rejects blobs larger than 100MB
+	maxBlobSize = 100 << 20
+	// A restore is not a full copy of the deleted repository, the response says what was restored
+	restoredMessage = "Repository restored from backup, the default branch holds the backed up files as a single commit, its history, tags and other branches are not restored"
+)
+
+// backupStore keeps backups on the local filesystem
+// Every backup gets its own directory and is recorded in a shared manifest
+type backupStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// storageError is a failure of the backup directory itself, as opposed to GitHub
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string {
+	return fmt.Sprintf("failed to write backup: %v", e.err)
+}
+
+func (e *storageError) Unwrap() error {
+	return e.err
+}
+
+// newBackupStore creates the backup directory if needed
+func newBackupStore(dir string) (*backupStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &backupStore{dir: dir}, nil
+}
+
+// list returns every backup recorded in the manifest
+func (s *backupStore) list() ([]models.Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readManifest()
+}
+
+// get returns the backup with the given ID
+func (s *backupStore) get(id string) (models.Backup, bool, error) {
+	backups, err := s.list()
+	if err != nil {
+		return models.Backup{}, false, err
+	}
+
+	for _, backup := range backups {
+		if backup.ID == id {
+			return backup, true, nil
+		}
+	}
+	return models.Backup{}, false, nil
+}
+
+// save records a backup in the manifest, replacing any entry with the same ID
+func (s *backupStore) save(backup models.Backup) error {
+	if err := s.writeManifest(backup); err != nil {
+		return &storageError{err: err}
+	}
+	return nil
+}
+
+// writeManifest rewrites the manifest with the given backup
+func (s *backupStore) writeManifest(backup models.Backup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backups, err := s.readManifest()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range backups {
+		if backups[i].ID == backup.ID {
+			backups[i] = backup
+			replaced = true
+		}
+	}
+	if !replaced {
+		backups = append(backups, backup)
+	}
+
+	data, err := json.MarshalIndent(backups, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated manifest
+	tmp := filepath.Join(s.dir, backupManifest+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, backupManifest))
+}
+
+// readManifest loads the manifest, callers must hold the lock
+func (s *backupStore) readManifest() ([]models.Backup, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, backupManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []models.Backup
+	if err := json.Unmarshal(data, &backups); err != nil {
+		return nil, fmt.Errorf("corrupted backup manifest: %w", err)
+	}
+	return backups, nil
+}
+
+// path returns the location of a file belonging to a backup
+func (s *backupStore) path(id, name string) string {
+	return filepath.Join(s.dir, id, name)
+}
+
+// writeJSON stores v as a JSON file inside the backup directory
+func (s *backupStore) writeJSON(id, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(id, name), data, 0o600); err != nil {
+		return &storageError{err: err}
+	}
+	return nil
+}
+
+// ListBackups returns every backup taken before a deletion
+func (a *Application) ListBackups(c *gin.Context) {
+	backups, err := a.backups.list()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, backups)
+}
+
+// RestoreBackup recreates a deleted repository and pushes the backed up files as a single commit
+// Backups only hold a snapshot of the default branch, so history, tags and other branches are lost
+// Issues, pull requests and releases stay in the backup for reference
+func (a *Application) RestoreBackup(c *gin.Context) {
+	backup, found, err := a.backups.get(c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, models.ErrCodeInternal, err.Error())
+		return
+	}
+	if !found {
+		respondWithError(c, http.StatusNotFound, models.ErrCodeNotFound, fmt.Sprintf("Backup '%s' does not exist", c.Param("id")))
+		return
+	}
+
+	// Repositories owned by the configured owner are created for the authenticated user
+	org := ""
+	if !strings.EqualFold(backup.Owner, a.owner) {
+		org = backup.Owner
+	}
+
+	ctx := context.Background()
+	newRepo, _, err := a.githubClient.Repositories.Create(ctx, org, &github.Repository{
+		Name:        github.Ptr(backup.Repository),
+		Description: github.Ptr(backup.Description),
+		Private:     github.Ptr(backup.Private),
+		AutoInit:    github.Ptr(backup.HasArchive),
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// The repository exists at this point, so failures are reported as warnings
+	var warnings []string
+	if backup.HasArchive {
+		if err := a.restoreArchive(ctx, newRepo, backup); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to restore git data: %v", err))
+		}
+	}
+
+	now := time.Now().UTC()
+	backup.RestoredAt = &now
+	if err := a.backups.save(backup); err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to update backup manifest: %v", err))
+	}
+
+	response := newRepoResponse(restoredMessage, newRepo)
+	response.Warnings = warnings
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// backupRepository saves the git data, issues, pull requests and releases of a repository
+// and records the backup in the manifest
+func (a *Application) backupRepository(ctx context.Context, repo string) (models.Backup, error) {
+	ghRepo, _, err := a.githubClient.Repositories.Get(ctx, a.owner, repo)
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	id, err := newBackupID(repo, time.Now())
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	now := time.Now().UTC()
+	backup := models.Backup{
+		ID:            id,
+		Repository:    repo,
+		Owner:         ghRepo.GetOwner().GetLogin(),
+		Description:   ghRepo.GetDescription(),
+		Private:       ghRepo.GetPrivate(),
+		DefaultBranch: ghRepo.GetDefaultBranch(),
+		CreatedAt:     now,
+	}
+
+	// Issues include pull requests, those are saved separately
+	issues, _, err := fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.Issue, *github.Response, error) {
+		return a.githubClient.Issues.ListByRepo(ctx, a.owner, repo, &github.IssueListByRepoOptions{State: "all", ListOptions: opts})
+	})
+	if err != nil {
+		return models.Backup{}, err
+	}
+	plainIssues := make([]*github.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			plainIssues = append(plainIssues, issue)
+		}
+	}
+
+	pullRequests, _, err := fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		return a.githubClient.PullRequests.List(ctx, a.owner, repo, &github.PullRequestListOptions{State: "all", ListOptions: opts})
+	})
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	releases, _, err := fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error) {
+		return a.githubClient.Repositories.ListReleases(ctx, a.owner, repo, &opts)
+	})
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(a.backups.dir, backup.ID), 0o750); err != nil {
+		return models.Backup{}, &storageError{err: err}
+	}
+
+	// Never leave a half written backup behind
+	if err := a.writeBackupFiles(ctx, &backup, ghRepo, plainIssues, pullRequests, releases); err != nil {
+		_ = os.RemoveAll(filepath.Join(a.backups.dir, backup.ID))
+		return models.Backup{}, err
+	}
+
+	backup.Issues = len(plainIssues)
+	backup.PullRequests = len(pullRequests)
+	backup.Releases = len(releases)
+	if err := a.backups.save(backup); err != nil {
+		return models.Backup{}, err
+	}
+
+	return backup, nil
+}
+
+// newBackupID names a backup after the repository and the time, a repository deleted, restored and deleted again
+// within the same second would otherwise overwrite its first backup, so a random suffix keeps IDs apart
+func newBackupID(repo string, now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s-%s", repo, now.UTC().Format("20060102150405"), hex.EncodeToString(suffix)), nil
+}
+
+// writeBackupFiles stores the metadata and git archive of a backup
+func (a *Application) writeBackupFiles(ctx context.Context, backup *models.Backup, repo *github.Repository, issues []*github.Issue, pullRequests []*github.PullRequest, releases []*github.RepositoryRelease) error {
+	files := map[string]any{
+		"repository.json":    repo,
+		"issues.json":        issues,
+		"pull_requests.json": pullRequests,
+		"releases.json":      releases,
+	}
+	for name, v := range files {
+		if err := a.backups.writeJSON(backup.ID, name, v); err != nil {
+			return err
+		}
+	}
+
+	// Empty repositories have no archive to download
+	if repo.GetSize() == 0 {
+		return nil
+	}
+	if err := a.downloadArchive(ctx, repo.GetName(), a.backups.path(backup.ID, backupArchive)); err != nil {
+		return err
+	}
+	backup.HasArchive = true
+
+	return nil
+}
+
+// downloadArchive saves the tarball of the default branch to dest
+func (a *Application) downloadArchive(ctx context.Context, repo, dest string) error {
+	link, _, err := a.githubClient.Repositories.GetArchiveLink(ctx, a.owner, repo, github.Tarball, nil, 1)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.githubClient.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download archive: %s", resp.Status)
+	}
+
+	file, err := os.Create(filepath.Clean(dest))
+	if err != nil {
+		return &storageError{err: err}
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return &storageError{err: err}
+	}
+	return nil
+}
+
+// restoreArchive replaces the initial commit of a restored repository with the backed up files
+func (a *Application) restoreArchive(ctx context.Context, repo *github.Repository, backup models.Backup) error {
+	archive, err := os.Open(a.backups.path(backup.ID, backupArchive))
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	files, err := filesFromTarball(archive)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	tree, err := a.createTree(ctx, owner, name, "", files)
+	if err != nil {
+		return err
+	}
+	commit, err := a.createCommit(ctx, owner, name, fmt.Sprintf("Restore from backup %s", backup.ID), tree, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	// Force the branch onto the restored commit, dropping the auto-generated one
+	branch := repo.GetDefaultBranch()
+	_, _, err = a.githubClient.Git.UpdateRef(ctx, owner, name, &github.Reference{
+		Ref:    github.Ptr("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	if backup.DefaultBranch != "" && backup.DefaultBranch != branch {
+		if _, _, err := a.githubClient.Repositories.RenameBranch(ctx, owner, name, branch, backup.DefaultBranch); err != nil {
+			return err
+		}
+		repo.DefaultBranch = github.Ptr(backup.DefaultBranch)
+	}
+
+	return nil
+}
+
+// filesFromTarball reads the files of a GitHub tarball
+// GitHub wraps everything in a single top-level directory which is stripped
+func filesFromTarball(r io.Reader) ([]gitFile, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var files []gitFile
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, path, found := strings.Cut(header.Name, "/")
+		if !found || path == "" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			content, err := io.ReadAll(io.LimitReader(tr, maxBlobSize+1))
+			if err != nil {
+				return nil, err
+			}
+			if len(content) > maxBlobSize {
+				return nil, fmt.Errorf("file %s is larger than 100MB", path)
+			}
+
+			mode := modeFile
+			if header.Mode&0o111 != 0 {
+				mode = modeExecutable
+			}
+			files = append(files, gitFile{Path: path, Mode: mode, Content: content})
+		case tar.TypeSymlink:
+			files = append(files, gitFile{Path: path, Mode: modeSymlink, Content: []byte(header.Linkname)})
+		}
+	}
+}
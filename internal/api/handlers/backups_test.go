@@ -0,0 +1,328 @@
+package handlers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupsOnGitHub(t *testing.T) {
+	// newFakeClient serves a repository with nothing to back up besides its metadata
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api", http.StatusOK, &github.Repository{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}})
+		fake.reply("GET /repos/owner/api/pulls", http.StatusOK, []*github.PullRequest{})
+		fake.reply("GET /repos/owner/api/releases", http.StatusOK, []*github.RepositoryRelease{})
+		return fake, app
+	}
+
+	// deleteOnGitHub confirms the deletion of 'api' and returns the final response
+	deleteOnGitHub := func(t *testing.T, app *handlers.Application) *httptest.ResponseRecorder {
+		w := serveJSON(t, app, "DELETE", "/repositories/api", "")
+
+		var confirmation models.DeleteConfirmationResponse
+		err := json.Unmarshal(w.Body.Bytes(), &confirmation)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		return serveJSON(t, app, "DELETE", "/repositories/api?confirm="+confirmation.Token, "")
+	}
+
+	t.Run("Delete records a backup", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("GET /repos/owner/api/issues", http.StatusOK, []*github.Issue{})
+		fake.reply("DELETE /repos/owner/api", http.StatusNoContent, nil)
+
+		w := deleteOnGitHub(t, app)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var deleted models.DeleteRepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &deleted)
+		assert.NoError(t, err, errJSONUnmarshal)
+		assert.NotEmpty(t, deleted.BackupID, "Delete should report the backup ID")
+
+		w = serveJSON(t, app, "GET", "/backups", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var backups []models.Backup
+		err = json.Unmarshal(w.Body.Bytes(), &backups)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, backups, 1, "There should be 1 backup")
+		assert.Equal(t, deleted.BackupID, backups[0].ID, "Backup ID should match the delete response")
+		assert.Equal(t, "api", backups[0].Repository, "Backup should reference 'api'")
+		assert.Nil(t, backups[0].RestoredAt, "Backup should not be restored yet")
+	})
+
+	t.Run("Delete downloads the repository archive", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api", http.StatusOK, &github.Repository{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}, Size: github.Ptr(1)})
+		fake.reply("GET /repos/owner/api/pulls", http.StatusOK, []*github.PullRequest{})
+		fake.reply("GET /repos/owner/api/releases", http.StatusOK, []*github.RepositoryRelease{})
+		fake.reply("GET /repos/owner/api/issues", http.StatusOK, []*github.Issue{})
+		fake.reply("DELETE /repos/owner/api", http.StatusNoContent, nil)
+		fake.handle("GET /repos/owner/api/tarball", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://"+r.Host+"/codeload/api.tar.gz", http.StatusFound)
+		})
+		fake.handle("GET /codeload/api.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("archive"))
+		})
+
+		w := deleteOnGitHub(t, app)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var deleted models.DeleteRepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &deleted)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		archive, err := os.ReadFile(filepath.Join(app.BackupDir(), deleted.BackupID, "repository.tar.gz"))
+		assert.NoError(t, err, "Archive should be saved")
+		assert.Equal(t, "archive", string(archive), "Archive should be saved as downloaded")
+	})
+
+	t.Run("Delete is refused when the archive cannot be downloaded", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api", http.StatusOK, &github.Repository{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}, Size: github.Ptr(1)})
+		fake.reply("GET /repos/owner/api/pulls", http.StatusOK, []*github.PullRequest{})
+		fake.reply("GET /repos/owner/api/releases", http.StatusOK, []*github.RepositoryRelease{})
+		fake.reply("GET /repos/owner/api/issues", http.StatusOK, []*github.Issue{})
+		fake.handle("GET /repos/owner/api/tarball", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://"+r.Host+"/codeload/api.tar.gz", http.StatusFound)
+		})
+		fake.reply("GET /codeload/api.tar.gz", http.StatusServiceUnavailable, map[string]string{"message": "Service unavailable"})
+
+		w := deleteOnGitHub(t, app)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+		assert.False(t, fake.called("DELETE /repos/owner/api"), "Repository should not be deleted without a backup")
+	})
+
+	t.Run("Restore a backup", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		writeBackup(t, app.BackupDir(), models.Backup{ID: "api-1", Repository: "api", Owner: "owner", Description: "test repo"}, nil)
+		fake.handle("POST /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			var repo github.Repository
+			readJSON(t, r, &repo)
+			assert.Equal(t, "test repo", repo.GetDescription(), "Description should be restored")
+			assert.False(t, repo.GetAutoInit(), "A backup without git data should not be initialized")
+
+			writeJSON(w, http.StatusCreated, &github.Repository{Name: github.Ptr("api"), Description: github.Ptr("test repo"), Owner: &github.User{Login: github.Ptr("owner")}})
+		})
+
+		w := serveJSON(t, app, "POST", "/backups/api-1/restore", "")
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "api", response.Name, "Restored repository name should match")
+		assert.Equal(t, "test repo", response.Description, "Restored repository description should match")
+
+		w = serveJSON(t, app, "GET", "/backups", "")
+
+		var backups []models.Backup
+		err = json.Unmarshal(w.Body.Bytes(), &backups)
+		assert.NoError(t, err, errJSONUnmarshal)
+		assert.NotNil(t, backups[0].RestoredAt, "Backup should be flagged as restored")
+	})
+
+	t.Run("Restore onto an existing repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		writeBackup(t, app.BackupDir(), models.Backup{ID: "api-1", Repository: "api", Owner: "owner"}, nil)
+		fake.reply("POST /user/repos", http.StatusUnprocessableEntity, map[string]any{
+			"message": "Repository creation failed.",
+			"errors":  []map[string]string{{"resource": "Repository", "field": "name", "code": "custom", "message": "name already exists on this account"}},
+		})
+
+		w := serveJSON(t, app, "POST", "/backups/api-1/restore", "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Restore unknown backup", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/backups/missing/restore", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+
+	t.Run("Backup directory failure is internal", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("GET /repos/owner/api/issues", http.StatusOK, []*github.Issue{})
+
+		// A file in place of the backup directory fails even when running as root
+		assert.NoError(t, os.RemoveAll(app.BackupDir()))
+		assert.NoError(t, os.WriteFile(app.BackupDir(), nil, 0o600))
+
+		w := deleteOnGitHub(t, app)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "Code should be 500 InternalServerError")
+		assert.Contains(t, w.Body.String(), models.ErrCodeInternal, "Error should not be blamed on GitHub")
+		assert.False(t, fake.called("DELETE /repos/owner/api"), "Repository should be kept")
+	})
+
+	t.Run("GitHub failure during the backup is upstream", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("GET /repos/owner/api/issues", http.StatusInternalServerError, map[string]string{"message": "Server Error"})
+
+		w := deleteOnGitHub(t, app)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 Bad Gateway")
+		assert.False(t, fake.called("DELETE /repos/owner/api"), "Repository should be kept")
+	})
+
+	t.Run("Backups within the same second get distinct IDs", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("GET /repos/owner/api/issues", http.StatusOK, []*github.Issue{})
+		fake.reply("DELETE /repos/owner/api", http.StatusNoContent, nil)
+
+		ids := map[string]bool{}
+		for range 2 {
+			w := deleteOnGitHub(t, app)
+			assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+			var deleted models.DeleteRepoResponse
+			err := json.Unmarshal(w.Body.Bytes(), &deleted)
+			assert.NoError(t, err, errJSONUnmarshal)
+			ids[deleted.BackupID] = true
+		}
+		assert.Len(t, ids, 2, "Every backup should get its own ID")
+
+		w := serveJSON(t, app, "GET", "/backups", "")
+
+		var backups []models.Backup
+		err := json.Unmarshal(w.Body.Bytes(), &backups)
+		assert.NoError(t, err, errJSONUnmarshal)
+		assert.Len(t, backups, 2, "The first backup should not be overwritten")
+	})
+
+	t.Run("Restore under the configured owner whatever its case", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		writeBackup(t, app.BackupDir(), models.Backup{ID: "api-1", Repository: "api", Owner: "Owner", DefaultBranch: "main"}, nil)
+		fake.reply("POST /user/repos", http.StatusCreated, &github.Repository{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}})
+
+		w := serveJSON(t, app, "POST", "/backups/api-1/restore", "")
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+		assert.True(t, fake.called("POST /user/repos"), "Repository should be created for the authenticated user")
+	})
+
+	t.Run("Restore the backed up files as a root commit", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		writeBackup(t, app.BackupDir(), models.Backup{ID: "api-1", Repository: "api", Owner: "owner", DefaultBranch: "main", HasArchive: true}, map[string]*tar.Header{
+			"owner-api-abc123/":          {Typeflag: tar.TypeDir},
+			"owner-api-abc123/README.md": {Typeflag: tar.TypeReg, Mode: 0o644},
+			"owner-api-abc123/run.sh":    {Typeflag: tar.TypeReg, Mode: 0o755},
+			"owner-api-abc123/docs":      {Typeflag: tar.TypeSymlink, Linkname: "README.md"},
+		})
+
+		fake.reply("POST /user/repos", http.StatusCreated, &github.Repository{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}, DefaultBranch: github.Ptr("main")})
+		fake.handle("POST /repos/owner/api/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+			var blob github.Blob
+			readJSON(t, r, &blob)
+			content, err := base64.StdEncoding.DecodeString(blob.GetContent())
+			assert.NoError(t, err, "Blobs should be sent as base64")
+
+			writeJSON(w, http.StatusCreated, &github.Blob{SHA: github.Ptr("blob:" + string(content))})
+		})
+		fake.handle("POST /repos/owner/api/git/trees", func(w http.ResponseWriter, r *http.Request) {
+			var tree struct {
+				BaseTree string              `json:"base_tree"`
+				Tree     []*github.TreeEntry `json:"tree"`
+			}
+			readJSON(t, r, &tree)
+			assert.Empty(t, tree.BaseTree, "The restored tree should not build on the initial commit")
+
+			entries := map[string]string{}
+			for _, entry := range tree.Tree {
+				entries[entry.GetPath()] = entry.GetMode() + " " + entry.GetSHA()
+			}
+			assert.Equal(t, map[string]string{
+				"README.md": "100644 blob:README.md",
+				"run.sh":    "100755 blob:run.sh",
+				"docs":      "120000 blob:README.md",
+			}, entries, "Every file should keep its mode and content")
+
+			writeJSON(w, http.StatusCreated, &github.Tree{SHA: github.Ptr("tree1")})
+		})
+		fake.handle("POST /repos/owner/api/git/commits", func(w http.ResponseWriter, r *http.Request) {
+			var commit struct {
+				Tree    string   `json:"tree"`
+				Parents []string `json:"parents"`
+			}
+			readJSON(t, r, &commit)
+			assert.Equal(t, "tree1", commit.Tree, "Commit should hold the restored tree")
+			assert.Empty(t, commit.Parents, "Restored commit should be a root commit")
+
+			writeJSON(w, http.StatusCreated, &github.Commit{SHA: github.Ptr("commit1")})
+		})
+		fake.handle("PATCH /repos/owner/api/git/refs/heads/main", func(w http.ResponseWriter, r *http.Request) {
+			var ref struct {
+				SHA   string `json:"sha"`
+				Force bool   `json:"force"`
+			}
+			readJSON(t, r, &ref)
+			assert.Equal(t, "commit1", ref.SHA, "Default branch should point to the restored commit")
+			assert.True(t, ref.Force, "The auto-generated commit should be replaced")
+
+			writeJSON(w, http.StatusOK, &github.Reference{Ref: github.Ptr("refs/heads/main"), Object: &github.GitObject{SHA: github.Ptr("commit1")}})
+		})
+
+		w := serveJSON(t, app, "POST", "/backups/api-1/restore", "")
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Empty(t, response.Warnings, "Git data should be restored")
+		assert.Contains(t, response.Message, "history, tags and other branches are not restored", "Response should state what is lost")
+		assert.True(t, fake.called("PATCH /repos/owner/api/git/refs/heads/main"), "Default branch should be updated")
+	})
+}
+
+// writeBackup records a backup in the manifest of dir along with a GitHub tarball of the given entries
+// Regular files hold their own name so blobs can be told apart
+func writeBackup(t *testing.T, dir string, backup models.Backup, entries map[string]*tar.Header) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, header := range entries {
+		header.Name = name
+		content := []byte(path.Base(name))
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(content))
+		}
+		assert.NoError(t, tw.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tw.Write(content)
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	manifest, err := json.Marshal([]models.Backup{backup})
+	assert.NoError(t, err, errJSONMarshal)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, backup.ID), 0o750))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, backup.ID, "repository.tar.gz"), archive.Bytes(), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.json"), manifest, 0o600))
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// ListBranches lists the branches of a repository with the SHA of their last commit
+// The list holds nothing else about the commit, '?details=true' fetches each branch in parallel for the rest
+func (a *Application) ListBranches(c *gin.Context) {
+	repo := c.Param("repo")
+
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	protected, err := parseOptionalBool(c, "protected")
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	details, err := parseOptionalBool(c, "details")
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	branches, err := fetchPages(c, p, a.maxResults, func(opts github.ListOptions) ([]*github.Branch, *github.Response, error) {
+		return a.githubClient.Repositories.ListBranches(ctx, a.owner, repo, &github.BranchListOptions{Protected: protected, ListOptions: opts})
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+	if details == nil || !*details {
+		c.JSON(http.StatusOK, newBranchResponses(branches, defaultBranch))
+		return
+	}
+
+	detailed, errs := fanOut(branches, fanOutConcurrency, func(branch *github.Branch) (*github.Branch, error) {
+		return a.getBranch(ctx, repo, branch.GetName())
+	})
+	for _, err := range errs {
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newBranchResponses(detailed, defaultBranch))
+}
+
+// GetBranch returns a single branch with its last commit
+func (a *Application) GetBranch(c *gin.Context) {
+	repo, name := c.Param("repo"), c.Param("branch")
+
+	ctx := context.Background()
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	branch, err := a.getBranch(ctx, repo, name)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newBranchResponse(branch, defaultBranch))
+}
+
+// CreateBranch creates a branch from a branch, a tag or a commit SHA, the default branch when none is given
+func (a *Application) CreateBranch(c *gin.Context) {
+	repo := c.Param("repo")
+
+	var req models.BranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	from := req.From
+	if from == "" {
+		from = defaultBranch
+	}
+
+	// Resolves branches, tags and abbreviated SHAs alike
+	sha, _, err := a.githubClient.Repositories.GetCommitSHA1(ctx, a.owner, repo, from, "")
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	_, _, err = a.githubClient.Git.CreateRef(ctx, a.owner, repo, &github.Reference{
+		Ref:    github.Ptr("refs/heads/" + req.Name),
+		Object: &github.GitObject{SHA: github.Ptr(sha)},
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	branch, err := a.getBranch(ctx, repo, req.Name)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newBranchResponse(branch, defaultBranch))
+}
+
+// RenameBranch renames a branch, GitHub retargets its pull requests and protection rules
+func (a *Application) RenameBranch(c *gin.Context) {
+	repo, name := c.Param("repo"), c.Param("branch")
+
+	var req models.BranchRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	branch, _, err := a.githubClient.Repositories.RenameBranch(ctx, a.owner, repo, name, req.Name)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Renaming the default branch changes the repository's default branch
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newBranchResponse(branch, defaultBranch))
+}
+
+// DeleteBranch deletes a branch once it is merged into the default branch, unless 'force=true' is given
+// The default branch and protected branches are never deleted
+func (a *Application) DeleteBranch(c *gin.Context) {
+	repo, name := c.Param("repo"), c.Param("branch")
+
+	force, err := parseOptionalBool(c, "force")
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	branch, err := a.getBranch(ctx, repo, name)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	if reason := branchDeleteBlocker(branch, defaultBranch); reason != "" {
+		respondWithError(c, http.StatusConflict, models.ErrCodeConflict, reason)
+		return
+	}
+
+	if force == nil || !*force {
+		merged, err := a.branchMerged(ctx, repo, defaultBranch, branch)
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+		if !merged {
+			respondWithError(c, http.StatusConflict, models.ErrCodeConflict, unmergedBranchMessage(name, defaultBranch))
+			return
+		}
+	}
+
+	if _, err := a.githubClient.Git.DeleteRef(ctx, a.owner, repo, "heads/"+name); err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DeleteBranchResponse{
+		Message: "Branch deleted successfully",
+		Branch:  name,
+		SHA:     branch.GetCommit().GetSHA(),
+	})
+}
+
+// PruneBranches deletes the branches merged into the default branch whose last commit is older than 'older_than'
+// Branches are checked in parallel then deleted one at a time, failures are reported per branch
+func (a *Application) PruneBranches(c *gin.Context) {
+	repo := c.Param("repo")
+	now := time.Now()
+
+	var req models.BranchPruneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	age, err := parseAge(req.OlderThan)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	cutoff := now.Add(-age)
+
+	ctx := context.Background()
+	defaultBranch, err := a.defaultBranch(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Protected branches are never pruned, so GitHub can leave them out
+	branches, truncated, err := fetchAllPages(maxPerPage, a.maxResults, func(opts github.ListOptions) ([]*github.Branch, *github.Response, error) {
+		return a.githubClient.Repositories.ListBranches(ctx, a.owner, repo, &github.BranchListOptions{Protected: github.Ptr(false), ListOptions: opts})
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+	setTruncatedHeader(c, truncated)
+
+	prune, errs := fanOut(branches, fanOutConcurrency, func(listed *github.Branch) (bool, error) {
+		branch, err := a.getBranch(ctx, repo, listed.GetName())
+		if err != nil || !isPruneCandidate(branch, defaultBranch, cutoff) {
+			return false, err
+		}
+		return a.branchMerged(ctx, repo, defaultBranch, branch)
+	})
+
+	if !req.DryRun {
+		for i, branch := range branches {
+			if errs[i] == nil && prune[i] {
+				_, errs[i] = a.githubClient.Git.DeleteRef(ctx, a.owner, repo, "heads/"+branch.GetName())
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, newBranchPruneResponse(branches, prune, errs, req.DryRun, cutoff))
+}
+
+// defaultBranch returns the default branch of a repository
+func (a *Application) defaultBranch(ctx context.Context, repo string) (string, error) {
+	repository, _, err := a.githubClient.Repositories.Get(ctx, a.owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return repository.GetDefaultBranch(), nil
+}
+
+// getBranch fetches a branch with its last commit
+// go-github's GetBranch skips the error handling, so a missing branch would not come back as a 404
+func (a *Application) getBranch(ctx context.Context, repo, name string) (*github.Branch, error) {
+	req, err := a.githubClient.NewRequest(http.MethodGet, fmt.Sprintf("repos/%v/%v/branches/%v", a.owner, repo, url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := new(github.Branch)
+	if _, err := a.githubClient.Do(ctx, req, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// branchMerged reports whether every commit of the branch is in the default branch,
+// or its last commit was merged through a pull request, which covers squash and rebase merges
+func (a *Application) branchMerged(ctx context.Context, repo, defaultBranch string, branch *github.Branch) (bool, error) {
+	comparison, _, err := a.githubClient.Repositories.CompareCommits(ctx, a.owner, repo, defaultBranch, branch.GetName(), &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return false, err
+	}
+	if comparison.GetAheadBy() == 0 {
+		return true, nil
+	}
+
+	prs, _, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, &github.PullRequestListOptions{
+		State:       "closed",
+		Head:        a.owner + ":" + branch.GetName(),
+		ListOptions: github.ListOptions{PerPage: maxPerPage},
+	})
+	if err != nil {
+		return false, err
+	}
+	return mergedThroughPullRequest(prs, branch), nil
+}
+
+// mergedThroughPullRequest reports whether one of the pull requests merged the branch at its current commit
+func mergedThroughPullRequest(prs []*github.PullRequest, branch *github.Branch) bool {
+	for _, pr := range prs {
+		if pr.MergedAt != nil && pr.GetHead().GetRef() == branch.GetName() && pr.GetHead().GetSHA() == branch.GetCommit().GetSHA() {
+			return true
+		}
+	}
+	return false
+}
+
+// branchDeleteBlocker explains why a branch can never be deleted, empty when it can
+func branchDeleteBlocker(branch *github.Branch, defaultBranch string) string {
+	switch {
+	case branch.GetName() == defaultBranch:
+		return fmt.Sprintf("Branch '%s' is the default branch", branch.GetName())
+	case branch.GetProtected():
+		return fmt.Sprintf("Branch '%s' is protected", branch.GetName())
+	}
+	return ""
+}
+
+// unmergedBranchMessage tells the client how to delete a branch that is not merged
+func unmergedBranchMessage(name, defaultBranch string) string {
+	return fmt.Sprintf("Branch '%s' is not merged into '%s', use force=true to delete it anyway", name, defaultBranch)
+}
+
+// isPruneCandidate reports whether a branch can be pruned and has not moved since the cutoff
+func isPruneCandidate(branch *github.Branch, defaultBranch string, cutoff time.Time) bool {
+	return branchDeleteBlocker(branch, defaultBranch) == "" && branchCommitDate(branch).Before(cutoff)
+}
+
+// branchCommitDate returns when the last commit of a branch was committed, falling back to when it was authored
+func branchCommitDate(branch *github.Branch) time.Time {
+	commit := branch.GetCommit().GetCommit()
+	if date := commit.GetCommitter().GetDate(); !date.IsZero() {
+		return date.Time
+	}
+	return commit.GetAuthor().GetDate().Time
+}
+
+// newBranchPruneResponse splits the checked branches into deleted and failed ones, unmerged branches are left out
+func newBranchPruneResponse(branches []*github.Branch, prune []bool, errs []error, dryRun bool, cutoff time.Time) models.BranchPruneResponse {
+	response := models.BranchPruneResponse{
+		DryRun:  dryRun,
+		Cutoff:  cutoff,
+		Deleted: []string{},
+	}
+	for i, branch := range branches {
+		switch {
+		case errs[i] != nil:
+			_, body := translateGitHubError(errs[i])
+			response.Failed = append(response.Failed, models.BranchError{Branch: branch.GetName(), Error: body.Error, Code: body.Code})
+		case prune[i]:
+			response.Deleted = append(response.Deleted, branch.GetName())
+		}
+	}
+	return response
+}
+
+// newBranchResponse converts a GitHub branch into the simplified format
+func newBranchResponse(branch *github.Branch, defaultBranch string) models.BranchResponse {
+	// Prefer the GitHub login, fall back to the git author for unknown emails
+	author := branch.GetCommit().GetAuthor().GetLogin()
+	if author == "" {
+		author = branch.GetCommit().GetCommit().GetAuthor().GetName()
+	}
+
+	response := models.BranchResponse{
+		Name:      branch.GetName(),
+		Protected: branch.GetProtected(),
+		Default:   branch.GetName() == defaultBranch,
+		Commit: models.BranchCommit{
+			SHA:     branch.GetCommit().GetSHA(),
+			Message: branch.GetCommit().GetCommit().GetMessage(),
+			Author:  author,
+		},
+	}
+	// Branch listings leave the commit details out
+	if date := branchCommitDate(branch); !date.IsZero() {
+		response.Commit.Date = &date
+	}
+	return response
+}
+
+// newBranchResponses converts a list of GitHub branches
+func newBranchResponses(branches []*github.Branch, defaultBranch string) []models.BranchResponse {
+	responses := make([]models.BranchResponse, 0, len(branches))
+	for _, branch := range branches {
+		responses = append(responses, newBranchResponse(branch, defaultBranch))
+	}
+	return responses
+}
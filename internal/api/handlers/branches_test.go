@@ -0,0 +1,419 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBranch builds a branch whose last commit was made at committedAt
+func testBranch(name, sha string, committedAt time.Time) *github.Branch {
+	return &github.Branch{
+		Name:      github.Ptr(name),
+		Protected: github.Ptr(false),
+		Commit: &github.RepositoryCommit{
+			SHA:    github.Ptr(sha),
+			Author: &github.User{Login: github.Ptr("alice")},
+			Commit: &github.Commit{
+				Message:   github.Ptr("Commit on " + name),
+				Committer: &github.CommitAuthor{Date: &github.Timestamp{Time: committedAt}},
+			},
+		},
+	}
+}
+
+// branchesOnGitHub holds the branches of 'test-repo' on a fake GitHub
+type branchesOnGitHub struct {
+	mu            sync.Mutex
+	defaultBranch string
+	branches      []*github.Branch
+	merged        []string // Branches with no commit ahead of the default branch
+	prs           []*github.PullRequest
+}
+
+// serve registers the branch endpoints of 'test-repo' on the fake
+func (b *branchesOnGitHub) serve(t *testing.T, fake *fakeGitHub) {
+	notFound := map[string]string{"message": "Branch not found"}
+
+	fake.handle("GET /repos/owner/test-repo", func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		writeJSON(w, http.StatusOK, github.Repository{Name: github.Ptr("test-repo"), DefaultBranch: github.Ptr(b.defaultBranch)})
+	})
+	fake.handle("GET /repos/owner/test-repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// Listings only hold the SHA of the last commit
+		listed := []*github.Branch{}
+		for _, branch := range b.branches {
+			if protected := r.URL.Query().Get("protected"); protected == "" || protected == strconv.FormatBool(branch.GetProtected()) {
+				listed = append(listed, &github.Branch{Name: branch.Name, Protected: branch.Protected, Commit: &github.RepositoryCommit{SHA: branch.Commit.SHA}})
+			}
+		}
+		writeJSON(w, http.StatusOK, listed)
+	})
+	fake.handle("GET /repos/owner/test-repo/branches/{branch}", func(w http.ResponseWriter, r *http.Request) {
+		if branch := b.find(r.PathValue("branch")); branch != nil {
+			writeJSON(w, http.StatusOK, branch)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, notFound)
+	})
+	fake.handle("GET /repos/owner/test-repo/commits/{ref}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/vnd.github.v3.sha", r.Header.Get("Accept"), "Only the SHA should be asked for")
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, branch := range b.branches {
+			if sha := branch.GetCommit().GetSHA(); branch.GetName() == r.PathValue("ref") || strings.HasPrefix(sha, r.PathValue("ref")) {
+				w.Write([]byte(sha))
+				return
+			}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "No commit found for SHA: " + r.PathValue("ref")})
+	})
+	fake.handle("POST /repos/owner/test-repo/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		var ref struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		}
+		readJSON(t, r, &ref)
+
+		name := strings.TrimPrefix(ref.Ref, "refs/heads/")
+		if b.find(name) != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+			return
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, branch := range b.branches {
+			if branch.GetCommit().GetSHA() == ref.SHA {
+				b.branches = append(b.branches, &github.Branch{Name: github.Ptr(name), Protected: github.Ptr(false), Commit: branch.Commit})
+				break
+			}
+		}
+		writeJSON(w, http.StatusCreated, github.Reference{Ref: github.Ptr(ref.Ref), Object: &github.GitObject{SHA: github.Ptr(ref.SHA)}})
+	})
+	fake.handle("POST /repos/owner/test-repo/branches/{branch}/rename", func(w http.ResponseWriter, r *http.Request) {
+		var rename struct {
+			NewName string `json:"new_name"`
+		}
+		readJSON(t, r, &rename)
+
+		branch := b.find(r.PathValue("branch"))
+		if branch == nil {
+			writeJSON(w, http.StatusNotFound, notFound)
+			return
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if branch.GetName() == b.defaultBranch {
+			b.defaultBranch = rename.NewName
+		}
+		branch.Name = github.Ptr(rename.NewName)
+		writeJSON(w, http.StatusCreated, branch)
+	})
+	fake.handle("GET /repos/owner/test-repo/compare/{basehead}", func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		base, head, _ := strings.Cut(r.PathValue("basehead"), "...")
+		assert.Equal(t, b.defaultBranch, base, "Branches should be compared with the default branch")
+
+		ahead := 1
+		if slices.Contains(b.merged, head) {
+			ahead = 0
+		}
+		writeJSON(w, http.StatusOK, github.CommitsComparison{AheadBy: github.Ptr(ahead)})
+	})
+	fake.handle("GET /repos/owner/test-repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "closed", r.URL.Query().Get("state"), "Only closed PRs can have merged a branch")
+
+		prs := []*github.PullRequest{}
+		for _, pr := range b.prs {
+			if r.URL.Query().Get("head") == "owner:"+pr.GetHead().GetRef() {
+				prs = append(prs, pr)
+			}
+		}
+		writeJSON(w, http.StatusOK, prs)
+	})
+	fake.handle("DELETE /repos/owner/test-repo/git/refs/heads/{branch...}", func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, branch := range b.branches {
+			if branch.GetName() == r.PathValue("branch") {
+				b.branches = slices.Delete(b.branches, i, i+1)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference does not exist"})
+	})
+}
+
+// find returns the branch with the given name or nil
+func (b *branchesOnGitHub) find(name string) *github.Branch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, branch := range b.branches {
+		if branch.GetName() == name {
+			return branch
+		}
+	}
+	return nil
+}
+
+// names lists the names of the remaining branches
+func (b *branchesOnGitHub) names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for _, branch := range b.branches {
+		names = append(names, branch.GetName())
+	}
+	return names
+}
+
+func TestBranches(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -60)
+
+	// newFakeClient serves 'test-repo' with a protected default branch, 'merged' and 'recent' are merged into it
+	// and 'feature/squashed' was squash merged through a pull request at its current commit
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application, *branchesOnGitHub) {
+		main := testBranch("main", "aaa111", time.Now())
+		main.Protected = github.Ptr(true)
+
+		squashed := testPullRequest(1, "closed", "alice", "feature/squashed", old)
+		squashed.Head.SHA = github.Ptr("ccc333")
+		squashed.MergedAt = &github.Timestamp{Time: old}
+
+		repo := &branchesOnGitHub{
+			defaultBranch: "main",
+			branches: []*github.Branch{
+				main,
+				testBranch("merged", "bbb222", old),
+				testBranch("feature/squashed", "ccc333", old),
+				testBranch("unmerged", "ddd444", old),
+				testBranch("recent", "eee555", time.Now()),
+			},
+			merged: []string{"merged", "recent"},
+			prs:    []*github.PullRequest{squashed},
+		}
+
+		fake, app := newFakeGitHub(t)
+		repo.serve(t, fake)
+		return fake, app, repo
+	}
+
+	t.Run("List branches without fetching each one", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response []models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response, 5, "There should be 5 branches")
+		assert.Equal(t, models.BranchResponse{Name: "main", Protected: true, Default: true, Commit: models.BranchCommit{SHA: "aaa111"}}, response[0], "main should be the protected default branch")
+		assert.Equal(t, "bbb222", response[1].Commit.SHA, "Last commit SHA should match")
+		assert.Empty(t, response[1].Commit.Author, "Commit details should only be fetched on request")
+		assert.False(t, fake.called("GET /repos/owner/test-repo/branches/merged"), "Branches should be built from the listing")
+	})
+
+	t.Run("List branches with their commit details", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches?details=true", "")
+
+		var response []models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "alice", response[1].Commit.Author, "Last commit author should match")
+		assert.NotNil(t, response[1].Commit.Date, "Last commit date should be set")
+	})
+
+	t.Run("List protected branches", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches?protected=true", "")
+
+		var response []models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response, 1, "Only main should be listed")
+	})
+
+	t.Run("Get a branch with a slash in its name", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches/feature%2Fsquashed", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "feature/squashed", response.Name, "Branch name should be decoded")
+	})
+
+	t.Run("Create a branch from a commit SHA", func(t *testing.T) {
+		_, app, repo := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches", `{"name": "hotfix", "from": "ddd4"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "ddd444", response.Commit.SHA, "Branch should start at the given commit")
+		assert.Contains(t, repo.names(), "hotfix", "Branch should be created")
+	})
+
+	t.Run("Create a branch from the default branch", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches", `{"name": "hotfix"}`)
+
+		var response models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "aaa111", response.Commit.SHA, "Branch should start at main")
+	})
+
+	t.Run("Create an existing branch", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches", `{"name": "merged"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Rename the default branch", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches/main/rename", `{"name": "trunk"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.BranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "trunk", response.Name, "Branch should be renamed")
+		assert.True(t, response.Default, "Renamed branch should stay the default branch")
+	})
+
+	t.Run("Delete a merged branch", func(t *testing.T) {
+		_, app, repo := newFakeClient(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo/branches/merged", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.DeleteBranchResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "bbb222", response.SHA, "Last commit should be returned")
+		assert.NotContains(t, repo.names(), "merged", "Branch should be deleted")
+	})
+
+	t.Run("Delete a squash merged branch", func(t *testing.T) {
+		_, app, repo := newFakeClient(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo/branches/feature%2Fsquashed", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.NotContains(t, repo.names(), "feature/squashed", "Branch should be deleted")
+	})
+
+	t.Run("Refuse to delete unmerged and default branches", func(t *testing.T) {
+		for _, branch := range []string{"unmerged", "main"} {
+			fake, app, _ := newFakeClient(t)
+
+			w := serveJSON(t, app, "DELETE", "/repositories/test-repo/branches/"+branch, "")
+
+			assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict for %s", branch)
+			assert.False(t, fake.called("DELETE /repos/owner/test-repo/git/refs/heads/"+branch), "%s should not be deleted", branch)
+		}
+	})
+
+	t.Run("Force delete an unmerged branch", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo/branches/unmerged?force=true", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.False(t, fake.called("GET /repos/owner/test-repo/compare/main...unmerged"), "Merge should not be checked")
+	})
+
+	t.Run("Prune merged branches older than the cutoff", func(t *testing.T) {
+		_, app, repo := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches/prune", `{"older_than": "30d"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.BranchPruneResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, []string{"merged", "feature/squashed"}, response.Deleted, "Only old merged branches should be pruned")
+		assert.Equal(t, []string{"main", "unmerged", "recent"}, repo.names(), "main, unmerged and recent should remain")
+	})
+
+	t.Run("Prune dry run", func(t *testing.T) {
+		_, app, repo := newFakeClient(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches/prune", `{"older_than": "30d", "dry_run": true}`)
+
+		var response models.BranchPruneResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.True(t, response.DryRun, "Response should be flagged as a dry run")
+		assert.Len(t, response.Deleted, 2, "2 branches would be pruned")
+		assert.Len(t, repo.names(), 5, "No branch should be deleted")
+	})
+
+	t.Run("Invalid prune age", func(t *testing.T) {
+		// Nothing is registered, the age is checked before calling GitHub
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/branches/prune", `{"older_than": "soon"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Branch does not exist", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches/missing", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// Most entries a cache holds, the entry closest to expiring makes room for a new one
+const maxCacheEntries = 1000
+
+// ttlCache keeps values in memory for a fixed time, safe for concurrent use
+type ttlCache[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// newTTLCache creates a cache whose values expire ttl after being set
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, maxEntries: maxCacheEntries, entries: make(map[string]cacheEntry[V])}
+}
+
+// get returns the value stored under key unless it has expired
+func (c *ttlCache[V]) get(key string, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// set stores value under key until the TTL elapses
+// Expired entries are dropped first, then the entry closest to expiring if the cache is still full
+func (c *ttlCache[V]) set(key string, value V, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// evict drops expired entries, or the oldest one when none has expired, callers must hold the lock
+func (c *ttlCache[V]) evict(now time.Time) {
+	oldest := ""
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == "" || entry.expiresAt.Before(c.entries[oldest].expiresAt) {
+			oldest = key
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// CreateCommit writes several files in a single commit through the Git data API and fast-forwards the branch to it
+// The commit is refused with a conflict when the branch no longer points to the given parent
+func (a *Application) CreateCommit(c *gin.Context) {
+	repo := c.Param("repo")
+
+	var req models.CommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	files, err := newGitFiles(req.Files)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	ref, _, err := a.githubClient.Git.GetRef(ctx, a.owner, repo, "heads/"+req.Branch)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+	if head := ref.GetObject().GetSHA(); head != req.Parent {
+		respondWithError(c, http.StatusConflict, models.ErrCodeConflict, branchMovedMessage(req.Branch, head, req.Parent))
+		return
+	}
+
+	parent, _, err := a.githubClient.Git.GetCommit(ctx, a.owner, repo, req.Parent)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	tree, err := a.createTree(ctx, a.owner, repo, parent.GetTree().GetSHA(), files)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	commit, err := a.createCommit(ctx, a.owner, repo, req.Message, tree, []string{req.Parent}, newCommitAuthor(req.Author))
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Without force GitHub only moves the branch to a descendant of its head, so a push made meanwhile is never lost
+	_, _, err = a.githubClient.Git.UpdateRef(ctx, a.owner, repo, &github.Reference{
+		Ref:    github.Ptr("heads/" + req.Branch),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}, false)
+	if isNotFastForward(err) {
+		message := fmt.Sprintf("Branch '%s' moved while commit %s was created, the branch was left unchanged", req.Branch, commit.GetSHA())
+		respondWithError(c, http.StatusConflict, models.ErrCodeConflict, message)
+		return
+	}
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCommitResponse(req, commit))
+}
+
+// newGitFiles decodes the files of a commit request, rejecting invalid and repeated paths
+func newGitFiles(files []models.CommitFile) ([]gitFile, error) {
+	gitFiles := make([]gitFile, 0, len(files))
+	seen := make(map[string]bool)
+	for _, file := range files {
+		filePath := strings.Trim(file.Path, "/")
+		if filePath == "" || path.Clean(filePath) != filePath || filePath == ".." || strings.HasPrefix(filePath, "../") {
+			return nil, fmt.Errorf("Invalid file path %q", file.Path)
+		}
+		if seen[filePath] {
+			return nil, fmt.Errorf("File %q is listed more than once", filePath)
+		}
+		seen[filePath] = true
+
+		content, err := decodeContent(file.Content, file.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid content for %q: %w", filePath, err)
+		}
+
+		gitFiles = append(gitFiles, gitFile{
+			Path:    filePath,
+			Mode:    file.Mode,
+			Content: content,
+			Delete:  file.Action == "delete",
+		})
+	}
+	return gitFiles, nil
+}
+
+// isNotFastForward reports whether GitHub refused to move a ref because the new commit does not descend from its head
+func isNotFastForward(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	return errResp.Response.StatusCode == http.StatusUnprocessableEntity && strings.Contains(strings.ToLower(errResp.Message), "fast forward")
+}
+
+// branchMovedMessage reports a branch whose head is no longer the expected parent
+func branchMovedMessage(branch, head, parent string) string {
+	return fmt.Sprintf("Branch '%s' is at %s, not at the expected parent %s", branch, head, parent)
+}
+
+// newCommitResponse converts the created commit
+func newCommitResponse(req models.CommitRequest, commit *github.Commit) models.CommitResponse {
+	return models.CommitResponse{
+		SHA:     commit.GetSHA(),
+		Tree:    commit.GetTree().GetSHA(),
+		Parent:  req.Parent,
+		Branch:  req.Branch,
+		Message: commit.GetMessage(),
+		URL:     commit.GetHTMLURL(),
+	}
+}
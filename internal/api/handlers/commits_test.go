@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// gitOnGitHub serves the Git data API of 'test-repo' on a fake GitHub
+// 'main' is at head, parent1 holds tree1 with the base files, and the commit created on top of it is commit1 holding tree2
+type gitOnGitHub struct {
+	mu     sync.Mutex
+	head   string
+	base   map[string]string
+	blobs  map[string]string
+	files  map[string]string
+	author *github.CommitAuthor
+}
+
+func (g *gitOnGitHub) serve(t *testing.T, fake *fakeGitHub) {
+	fake.handle("GET /repos/owner/test-repo/git/ref/heads/{branch...}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("branch") != "main" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, &github.Reference{Ref: github.Ptr("refs/heads/main"), Object: &github.GitObject{SHA: github.Ptr(g.head)}})
+	})
+	fake.reply("GET /repos/owner/test-repo/git/commits/parent1", http.StatusOK, &github.Commit{SHA: github.Ptr("parent1"), Tree: &github.Tree{SHA: github.Ptr("tree1")}})
+	fake.handle("POST /repos/owner/test-repo/git/blobs", func(w http.ResponseWriter, r *http.Request) {
+		var blob github.Blob
+		readJSON(t, r, &blob)
+		assert.Equal(t, "base64", blob.GetEncoding(), "Content should be sent as base64")
+		content, err := base64.StdEncoding.DecodeString(blob.GetContent())
+		assert.NoError(t, err, "Content should be valid base64")
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		sha := gitBlobSHA(content)
+		g.blobs[sha] = string(content)
+		writeJSON(w, http.StatusCreated, &github.Blob{SHA: github.Ptr(sha)})
+	})
+	fake.handle("POST /repos/owner/test-repo/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		var tree struct {
+			BaseTree string           `json:"base_tree"`
+			Tree     []map[string]any `json:"tree"`
+		}
+		readJSON(t, r, &tree)
+		assert.Equal(t, "tree1", tree.BaseTree, "Tree should build on the tree of the parent")
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		files := maps.Clone(g.base)
+		for _, entry := range tree.Tree {
+			filePath, _ := entry["path"].(string)
+			sha, ok := entry["sha"]
+			if !ok {
+				t.Errorf("%s should be sent with a SHA, null for a deleted file", filePath)
+			}
+			if sha != nil {
+				files[filePath] = g.blobs[sha.(string)]
+				continue
+			}
+			if _, exists := files[filePath]; !exists {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "GitRPC::BadObjectState"})
+				return
+			}
+			delete(files, filePath)
+		}
+		g.files = files
+		writeJSON(w, http.StatusCreated, &github.Tree{SHA: github.Ptr("tree2")})
+	})
+	fake.handle("POST /repos/owner/test-repo/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		var commit struct {
+			Message string               `json:"message"`
+			Tree    string               `json:"tree"`
+			Parents []string             `json:"parents"`
+			Author  *github.CommitAuthor `json:"author"`
+		}
+		readJSON(t, r, &commit)
+		assert.Equal(t, "tree2", commit.Tree, "Commit should hold the new tree")
+		assert.Equal(t, []string{"parent1"}, commit.Parents, "Commit should descend from the parent")
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.author = commit.Author
+		writeJSON(w, http.StatusCreated, &github.Commit{SHA: github.Ptr("commit1"), Tree: &github.Tree{SHA: github.Ptr("tree2")}, Message: github.Ptr(commit.Message)})
+	})
+}
+
+func TestCreateCommit(t *testing.T) {
+	const body = `{
+		"branch": "main",
+		"parent": "parent1",
+		"message": "Update docs",
+		"author": {"name": "Config Bot", "email": "bot@example.com"},
+		"files": [
+			{"path": "README.md", "content": "# Updated\n"},
+			{"path": "docs/logo.png", "content": "iVBORw0KGgoA", "encoding": "base64"},
+			{"path": "old.txt", "action": "delete"}
+		]
+	}`
+
+	newFakeClient := func(t *testing.T, head string) (*fakeGitHub, *handlers.Application, *gitOnGitHub) {
+		git := &gitOnGitHub{
+			head:  head,
+			base:  map[string]string{"README.md": "# Test\n", "old.txt": "old\n"},
+			blobs: map[string]string{},
+		}
+
+		fake, app := newFakeGitHub(t)
+		git.serve(t, fake)
+		return fake, app, git
+	}
+
+	t.Run("Write and delete files in one commit", func(t *testing.T) {
+		fake, app, git := newFakeClient(t, "parent1")
+		fake.handle("PATCH /repos/owner/test-repo/git/refs/heads/main", func(w http.ResponseWriter, r *http.Request) {
+			var ref struct {
+				SHA   string `json:"sha"`
+				Force bool   `json:"force"`
+			}
+			readJSON(t, r, &ref)
+			assert.Equal(t, "commit1", ref.SHA, "Branch should move to the new commit")
+			assert.False(t, ref.Force, "Branch should only be fast-forwarded")
+
+			writeJSON(w, http.StatusOK, &github.Reference{Ref: github.Ptr("refs/heads/main"), Object: &github.GitObject{SHA: github.Ptr("commit1")}})
+		})
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", body)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.CommitResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "commit1", response.SHA, "Commit SHA should match")
+		assert.Equal(t, "tree2", response.Tree, "Tree SHA should match")
+		assert.Equal(t, "parent1", response.Parent, "Parent should match")
+		assert.Equal(t, "Update docs", response.Message, "Message should match")
+		assert.Equal(t, map[string]string{
+			"README.md":     "# Updated\n",
+			"docs/logo.png": "\x89PNG\r\n\x1a\n\x00",
+		}, git.files, "Files should be written and deleted")
+		assert.Equal(t, "Config Bot", git.author.GetName(), "Author should be sent")
+	})
+
+	t.Run("Branch moved before the commit", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t, "other1")
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", body)
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.False(t, fake.called("POST /repos/owner/test-repo/git/blobs"), "Nothing should be written")
+	})
+
+	t.Run("Branch moved while the commit was created", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t, "parent1")
+		fake.reply("PATCH /repos/owner/test-repo/git/refs/heads/main", http.StatusUnprocessableEntity, map[string]string{"message": "Update is not a fast forward"})
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", body)
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.Contains(t, w.Body.String(), "commit1", "Response should name the commit left behind")
+	})
+
+	t.Run("Other ref update failures are reported as is", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t, "parent1")
+		fake.reply("PATCH /repos/owner/test-repo/git/refs/heads/main", http.StatusUnprocessableEntity, map[string]string{"message": "Reference cannot be updated"})
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", body)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Delete a file that does not exist", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t, "parent1")
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", `{"branch": "main", "parent": "parent1", "message": "Update", "files": [{"path": "README.md", "content": "x"}, {"path": "missing.txt", "action": "delete"}]}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+		assert.False(t, fake.called("POST /repos/owner/test-repo/git/commits"), "No commit should be created")
+	})
+
+	t.Run("Branch does not exist", func(t *testing.T) {
+		_, app, _ := newFakeClient(t, "parent1")
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", `{"branch": "missing", "parent": "parent1", "message": "Update", "files": [{"path": "a.txt"}]}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+
+	t.Run("Invalid commit requests", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+		}{
+			{"No files", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": []}`},
+			{"Missing parent", `{"branch": "main", "message": "Update", "files": [{"path": "a.txt"}]}`},
+			{"Unknown action", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": [{"path": "a.txt", "action": "rename"}]}`},
+			{"Invalid mode", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": [{"path": "a.txt", "mode": "777"}]}`},
+			{"Path escaping the repository", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": [{"path": "../a.txt"}]}`},
+			{"Repeated path", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": [{"path": "a.txt"}, {"path": "/a.txt", "action": "delete"}]}`},
+			{"Invalid base64", `{"branch": "main", "parent": "aaa111", "message": "Update", "files": [{"path": "a.png", "content": "%%%", "encoding": "base64"}]}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Nothing is registered, invalid requests must not reach GitHub
+				_, app := newFakeGitHub(t)
+
+				w := serveJSON(t, app, "POST", "/repositories/test-repo/commits", tt.body)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+			})
+		}
+	})
+}
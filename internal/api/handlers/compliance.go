@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/go-github/v68/github"
+	"gopkg.in/yaml.v3"
+)
+
+// compliancePolicy holds the rules every repository is evaluated against, loaded from the COMPLIANCE_POLICY file
+type compliancePolicy struct {
+	Rules           complianceRules `yaml:"rules"`
+	Exclude         []string        `yaml:"exclude"`          // Patterns such as 'sandbox-*' of repositories exempt from every rule
+	IncludeArchived bool            `yaml:"include_archived"` // Archived repositories are read-only so they are skipped by default
+}
+
+type complianceRules struct {
+	Private                 bool                           `yaml:"private"`
+	Description             bool                           `yaml:"description"`
+	License                 bool                           `yaml:"license"`
+	NoDirectPushes          bool                           `yaml:"no_direct_pushes"`
+	DefaultBranchProtection *models.BranchProtectionPolicy `yaml:"default_branch_protection"` // Protection the default branch must match
+}
+
+// complianceFixer applies the automatic fixes, on GitHub or on the mock
+type complianceFixer interface {
+	makePrivate(repo *github.Repository) (*github.Repository, error)
+	protectBranch(repo *github.Repository, req *github.ProtectionRequest) (*github.Protection, error)
+}
+
+// loadCompliancePolicy reads the policy file, no path means compliance is not configured
+func loadCompliancePolicy(file string) (*compliancePolicy, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compliance policy: %w", err)
+	}
+	return parseCompliancePolicy(data)
+}
+
+// parseCompliancePolicy decodes and validates a YAML policy, unknown keys are rejected so typos do not disable a rule
+func parseCompliancePolicy(data []byte) (*compliancePolicy, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var policy compliancePolicy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid compliance policy: %w", err)
+	}
+
+	for _, pattern := range policy.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid excluded repository pattern %q: %w", pattern, err)
+		}
+	}
+
+	if protection := policy.Rules.DefaultBranchProtection; protection != nil {
+		if err := binding.Validator.ValidateStruct(protection); err != nil {
+			return nil, fmt.Errorf("invalid default branch protection: %w", err)
+		}
+		normalized := normalizePolicy(*protection)
+		policy.Rules.DefaultBranchProtection = &normalized
+	}
+
+	return &policy, nil
+}
+
+// GetCompliance evaluates every repository against the compliance policy
+// The repositories are picked with the same filters as ListRepositories, failures are reported per repository
+func (a *Application) GetCompliance(c *gin.Context) {
+	now := time.Now()
+	if a.compliance == nil {
+		respondWithComplianceNotConfigured(c)
+		return
+	}
+
+	filter, err := parseRepoFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	repos, protections, errs, err := a.complianceTargets(ctx, filter)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newComplianceReport(a.compliance, repos, protections, errs, now))
+}
+
+// RemediateCompliance fixes the violations that can be fixed automatically
+// Repositories are fixed one at a time, then evaluated again to report what remains
+func (a *Application) RemediateCompliance(c *gin.Context) {
+	if a.compliance == nil {
+		respondWithComplianceNotConfigured(c)
+		return
+	}
+
+	filter, err := parseRepoFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	// An empty body remediates every covered repository
+	var req models.RemediationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	repos, protections, errs, err := a.complianceTargets(ctx, filter)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	fixer := githubComplianceFixer{a: a, ctx: ctx}
+	c.JSON(http.StatusOK, remediateRepositories(a.compliance, req, repos, protections, errs, fixer))
+}
+
+// complianceTargets lists the repositories covered by the policy and fetches their default branch protection in parallel
+func (a *Application) complianceTargets(ctx context.Context, filter repoFilter) ([]*github.Repository, []*github.Protection, []error, error) {
+	repos, _, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return a.githubClient.Repositories.ListByAuthenticatedUser(ctx, filter.listOptions(listOpts))
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	repos = a.compliance.covered(filter.apply(repos))
+	if !a.compliance.Rules.needsProtection() {
+		return repos, make([]*github.Protection, len(repos)), make([]error, len(repos)), nil
+	}
+
+	// Member repositories belong to other accounts, so each one is read and fixed under its own owner
+	protections, errs := fanOut(repos, fanOutConcurrency, func(repo *github.Repository) (*github.Protection, error) {
+		protection, _, err := a.githubClient.Repositories.GetBranchProtection(ctx, a.ownerOf(repo), repo.GetName(), repo.GetDefaultBranch())
+		if errors.Is(err, github.ErrBranchNotProtected) {
+			return nil, nil
+		}
+		return protection, err
+	})
+	return repos, protections, errs, nil
+}
+
+// githubComplianceFixer applies the compliance fixes on GitHub
+type githubComplianceFixer struct {
+	a   *Application
+	ctx context.Context
+}
+
+func (f githubComplianceFixer) makePrivate(repo *github.Repository) (*github.Repository, error) {
+	updated, _, err := f.a.githubClient.Repositories.Edit(f.ctx, f.a.ownerOf(repo), repo.GetName(), &github.Repository{Private: github.Ptr(true)})
+	return updated, err
+}
+
+func (f githubComplianceFixer) protectBranch(repo *github.Repository, req *github.ProtectionRequest) (*github.Protection, error) {
+	protection, _, err := f.a.githubClient.Repositories.UpdateBranchProtection(f.ctx, f.a.ownerOf(repo), repo.GetName(), repo.GetDefaultBranch(), req)
+	return protection, err
+}
+
+// covered keeps the repositories the policy applies to
+func (p *compliancePolicy) covered(repos []*github.Repository) []*github.Repository {
+	var covered []*github.Repository
+	for _, repo := range repos {
+		if repo.GetArchived() && !p.IncludeArchived {
+			continue
+		}
+		if p.excludes(repo.GetName()) {
+			continue
+		}
+		covered = append(covered, repo)
+	}
+	return covered
+}
+
+// excludes reports whether the repository matches one of the excluded patterns
+func (p *compliancePolicy) excludes(repo string) bool {
+	for _, pattern := range p.Exclude {
+		if matched, _ := path.Match(pattern, repo); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// needsProtection reports whether a rule looks at the default branch protection
+func (r complianceRules) needsProtection() bool {
+	return r.NoDirectPushes || r.DefaultBranchProtection != nil
+}
+
+// evaluateCompliance lists the rules a repository breaks, protection is nil when the default branch is not protected
+func evaluateCompliance(policy *compliancePolicy, repo *github.Repository, protection *github.Protection) []models.Violation {
+	rules := policy.Rules
+	violations := []models.Violation{}
+
+	if rules.Private && !repo.GetPrivate() {
+		violations = append(violations, models.Violation{Rule: models.RulePrivate, Message: "Repository is public", Fixable: true})
+	}
+	if rules.Description && strings.TrimSpace(repo.GetDescription()) == "" {
+		violations = append(violations, models.Violation{Rule: models.RuleDescription, Message: "Repository has no description"})
+	}
+	if rules.License && repo.GetLicense() == nil {
+		violations = append(violations, models.Violation{Rule: models.RuleLicense, Message: "Repository has no license"})
+	}
+
+	var current *models.BranchProtectionPolicy
+	if protection != nil {
+		p := newProtectionPolicy(protection)
+		current = &p
+	}
+	branch := repo.GetDefaultBranch()
+
+	if rules.DefaultBranchProtection != nil {
+		if changes := protectionChanges(current, *rules.DefaultBranchProtection); len(changes) > 0 {
+			message := fmt.Sprintf("Default branch '%s' is not protected", branch)
+			if current != nil {
+				fields := make([]string, 0, len(changes))
+				for _, change := range changes {
+					fields = append(fields, change.Field)
+				}
+				message = fmt.Sprintf("Default branch '%s' protection differs from the policy: %s", branch, strings.Join(fields, ", "))
+			}
+			violations = append(violations, models.Violation{Rule: models.RuleDefaultBranchProtection, Message: message, Fixable: true})
+		}
+	}
+
+	// Applying the protection of the policy fixes direct pushes when that protection blocks them
+	if rules.NoDirectPushes && !blocksDirectPushes(current) {
+		violations = append(violations, models.Violation{
+			Rule:    models.RuleNoDirectPushes,
+			Message: fmt.Sprintf("Default branch '%s' accepts direct pushes", branch),
+			Fixable: blocksDirectPushes(rules.DefaultBranchProtection),
+		})
+	}
+
+	return violations
+}
+
+// blocksDirectPushes reports whether a protection forces every change, administrators' included, through a reviewed pull request
+func blocksDirectPushes(policy *models.BranchProtectionPolicy) bool {
+	return policy != nil && policy.RequiredReviews != nil && policy.EnforceAdmins && !policy.AllowForcePushes
+}
+
+// newComplianceReport evaluates the repositories, those whose protection could not be fetched are reported as errors
+func newComplianceReport(policy *compliancePolicy, repos []*github.Repository, protections []*github.Protection, errs []error, now time.Time) models.ComplianceReport {
+	report := models.ComplianceReport{GeneratedAt: now, Repositories: []models.RepoCompliance{}}
+	for i, repo := range repos {
+		if errs[i] != nil {
+			report.Errors = append(report.Errors, newRepoError(repo.GetName(), errs[i]))
+			continue
+		}
+
+		violations := evaluateCompliance(policy, repo, protections[i])
+		report.Repositories = append(report.Repositories, models.RepoCompliance{
+			Repository: repo.GetName(),
+			Compliant:  len(violations) == 0,
+			Violations: violations,
+		})
+		if len(violations) == 0 {
+			report.Compliant++
+		}
+	}
+	report.Total = len(report.Repositories)
+	return report
+}
+
+// remediateRepositories fixes the requested repositories that have fixable violations
+func remediateRepositories(policy *compliancePolicy, req models.RemediationRequest, repos []*github.Repository, protections []*github.Protection, errs []error, fixer complianceFixer) models.RemediationReport {
+	report := models.RemediationReport{DryRun: req.DryRun, Repositories: []models.RepoRemediation{}}
+	for i, repo := range repos {
+		if len(req.Repositories) > 0 && !containsFold(req.Repositories, repo.GetName()) {
+			continue
+		}
+		if errs[i] != nil {
+			report.Errors = append(report.Errors, newRepoError(repo.GetName(), errs[i]))
+			continue
+		}
+
+		if result, ok := remediateRepo(policy, repo, protections[i], fixer, req.DryRun); ok {
+			report.Repositories = append(report.Repositories, result)
+		}
+	}
+	return report
+}
+
+// remediateRepo applies the fixes of a repository then evaluates it again, false when nothing can be fixed
+// On a dry run the fixable violations are reported as fixed and the others as remaining
+func remediateRepo(policy *compliancePolicy, repo *github.Repository, protection *github.Protection, fixer complianceFixer, dryRun bool) (models.RepoRemediation, bool) {
+	before := evaluateCompliance(policy, repo, protection)
+	result := models.RepoRemediation{Repository: repo.GetName(), Fixed: []string{}, Remaining: []models.Violation{}}
+
+	fixable := false
+	for _, violation := range before {
+		fixable = fixable || violation.Fixable
+	}
+	if !fixable {
+		return result, false
+	}
+
+	if dryRun {
+		for _, violation := range before {
+			if violation.Fixable {
+				result.Fixed = append(result.Fixed, violation.Rule)
+			} else {
+				result.Remaining = append(result.Remaining, violation)
+			}
+		}
+		return result, true
+	}
+
+	for _, violation := range before {
+		var err error
+		switch violation.Rule {
+		case models.RulePrivate:
+			var updated *github.Repository
+			if updated, err = fixer.makePrivate(repo); err == nil {
+				repo = updated
+			}
+		case models.RuleDefaultBranchProtection:
+			var updated *github.Protection
+			req := newProtectionRequest(*policy.Rules.DefaultBranchProtection, protection)
+			if updated, err = fixer.protectBranch(repo, req); err == nil {
+				protection = updated
+			}
+		}
+
+		if err != nil {
+			_, body := translateGitHubError(err)
+			result.Failed = append(result.Failed, models.RuleError{Rule: violation.Rule, Error: body.Error, Code: body.Code})
+		}
+	}
+
+	// Fixes can resolve other rules too, such as the protection blocking direct pushes
+	result.Remaining = evaluateCompliance(policy, repo, protection)
+	remaining := make(map[string]bool)
+	for _, violation := range result.Remaining {
+		remaining[violation.Rule] = true
+	}
+	for _, violation := range before {
+		if !remaining[violation.Rule] {
+			result.Fixed = append(result.Fixed, violation.Rule)
+		}
+	}
+	return result, true
+}
+
+// respondWithComplianceNotConfigured reports that no COMPLIANCE_POLICY file was given
+func respondWithComplianceNotConfigured(c *gin.Context) {
+	respondWithError(c, http.StatusNotFound, models.ErrCodeNotFound, "No compliance policy is configured, set COMPLIANCE_POLICY")
+}
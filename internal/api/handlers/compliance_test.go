@@ -0,0 +1,278 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCompliancePolicy = `
+exclude: [sandbox-*]
+rules:
+  private: true
+  description: true
+  license: true
+  no_direct_pushes: true
+  default_branch_protection:
+    required_reviews:
+      required_approving_review_count: 1
+    enforce_admins: true
+`
+
+func TestCompliance(t *testing.T) {
+	// newFakeClient serves the repositories of 'owner' and the protection of their default branch
+	// Reading the protection of the 'broken' repository fails
+	newFakeClient := func(t *testing.T, broken string) (*fakeGitHub, *handlers.Application, []*github.Repository, map[string]map[string]*github.Protection) {
+		repos := []*github.Repository{
+			{
+				Name:          github.Ptr("compliant"),
+				Private:       github.Ptr(true),
+				Description:   github.Ptr("Compliant repository"),
+				License:       &github.License{Key: github.Ptr("mit")},
+				DefaultBranch: github.Ptr("main"),
+			},
+			{Name: github.Ptr("public"), DefaultBranch: github.Ptr("main")},
+			{Name: github.Ptr("sandbox-test"), DefaultBranch: github.Ptr("main")},
+			{Name: github.Ptr("archived"), Archived: github.Ptr(true), DefaultBranch: github.Ptr("main")},
+		}
+		protections := map[string]map[string]*github.Protection{
+			"compliant": {
+				"main": {
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequiredApprovingReviewCount: 1},
+					EnforceAdmins:              &github.AdminEnforcement{Enabled: true},
+				},
+			},
+			"public": {},
+		}
+
+		fake, app := newFakeGitHub(t)
+		assert.NoError(t, app.SetCompliancePolicy(testCompliancePolicy), "Policy should be valid")
+
+		fake.handle("GET /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "owner", r.URL.Query().Get("type"), "Only owned repositories should be listed by default")
+			writeJSON(w, http.StatusOK, repos)
+		})
+		fake.handle("PATCH /repos/owner/public", func(w http.ResponseWriter, r *http.Request) {
+			var edit github.Repository
+			readJSON(t, r, &edit)
+			repos[1].Private = edit.Private
+			writeJSON(w, http.StatusOK, repos[1])
+		})
+		for name, branches := range protections {
+			if name == broken {
+				fake.reply("GET /repos/owner/"+name+"/branches/main/protection", http.StatusServiceUnavailable, map[string]string{"message": "Service unavailable"})
+				continue
+			}
+			serveProtections(t, fake, name, branches)
+		}
+		return fake, app, repos, protections
+	}
+
+	t.Run("Report violations", func(t *testing.T) {
+		_, app, _, _ := newFakeClient(t, "")
+
+		w := serveJSON(t, app, "GET", "/compliance", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.ComplianceReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 2, response.Total, "Excluded and archived repositories should be skipped")
+		assert.Equal(t, 1, response.Compliant, "1 repository should be compliant")
+		assert.True(t, response.Repositories[0].Compliant, "compliant should have no violation")
+
+		rules := map[string]bool{}
+		for _, violation := range response.Repositories[1].Violations {
+			rules[violation.Rule] = violation.Fixable
+		}
+		assert.Equal(t, map[string]bool{
+			models.RulePrivate:                 true,
+			models.RuleDescription:             false,
+			models.RuleLicense:                 false,
+			models.RuleDefaultBranchProtection: true,
+			models.RuleNoDirectPushes:          true,
+		}, rules, "public should break every rule")
+	})
+
+	t.Run("Report repositories that cannot be evaluated", func(t *testing.T) {
+		_, app, _, _ := newFakeClient(t, "public")
+
+		w := serveJSON(t, app, "GET", "/compliance", "")
+
+		var response models.ComplianceReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 1, response.Total, "Only compliant should be evaluated")
+		assert.Len(t, response.Errors, 1, "public should be reported as an error")
+	})
+
+	t.Run("Remediate fixable violations", func(t *testing.T) {
+		_, app, repos, protections := newFakeClient(t, "")
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate", `{}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.RemediationReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response.Repositories, 1, "Only public has fixable violations")
+		result := response.Repositories[0]
+		assert.ElementsMatch(t, []string{models.RulePrivate, models.RuleDefaultBranchProtection, models.RuleNoDirectPushes}, result.Fixed, "Fixable rules should be fixed")
+		assert.Len(t, result.Remaining, 2, "Description and license should remain")
+		assert.True(t, repos[1].GetPrivate(), "Repository should be made private")
+		assert.NotNil(t, protections["public"]["main"], "Default branch should be protected")
+	})
+
+	t.Run("Remediation dry run", func(t *testing.T) {
+		fake, app, _, _ := newFakeClient(t, "")
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate", `{"repositories": ["public"], "dry_run": true}`)
+
+		var response models.RemediationReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.True(t, response.DryRun, "Response should be flagged as a dry run")
+		assert.Len(t, response.Repositories[0].Fixed, 3, "3 rules would be fixed")
+		assert.False(t, fake.called("PATCH /repos/owner/public"), "Repository should stay public")
+		assert.False(t, fake.called("PUT /repos/owner/public/branches/main/protection"), "Default branch should stay unprotected")
+	})
+
+	t.Run("No compliance policy", func(t *testing.T) {
+		// Nothing is registered, compliance must not reach GitHub without a policy
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "GET", "/compliance", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+
+	t.Run("Unknown rule in the policy", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		err := app.SetCompliancePolicy("rules:\n  privat: true\n")
+
+		assert.Error(t, err, "Policy with a typo should be rejected")
+	})
+
+	const memberPolicy = `
+rules:
+  private: true
+  default_branch_protection:
+    enforce_admins: true
+`
+	// newMemberFakeClient serves a compliant 'api' and a public 'shared' belonging to another account
+	newMemberFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application) {
+		fake, app := newFakeGitHub(t)
+		assert.NoError(t, app.SetCompliancePolicy(memberPolicy), "Policy should be valid")
+
+		// 'shared' belongs to another account the token owner is a member of
+		shared := &github.Repository{Name: github.Ptr("shared"), Owner: &github.User{Login: github.Ptr("other")}, DefaultBranch: github.Ptr("main")}
+		fake.reply("GET /user/repos", http.StatusOK, []*github.Repository{
+			{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}, DefaultBranch: github.Ptr("main"), Private: github.Ptr(true)},
+			shared,
+		})
+		fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusOK, &github.Protection{EnforceAdmins: &github.AdminEnforcement{Enabled: true}})
+		fake.reply("GET /repos/other/shared/branches/main/protection", http.StatusNotFound, map[string]string{"message": "Branch not protected"})
+		fake.handle("PATCH /repos/other/shared", func(w http.ResponseWriter, r *http.Request) {
+			var edit github.Repository
+			readJSON(t, r, &edit)
+			assert.True(t, edit.GetPrivate(), "Repository should be made private")
+
+			updated := *shared
+			updated.Private = github.Ptr(true)
+			writeJSON(w, http.StatusOK, &updated)
+		})
+		return fake, app
+	}
+
+	t.Run("Remediate a member repository under its owner", func(t *testing.T) {
+		fake, app := newMemberFakeClient(t)
+		fake.handle("PUT /repos/other/shared/branches/main/protection", func(w http.ResponseWriter, r *http.Request) {
+			var req github.ProtectionRequest
+			readJSON(t, r, &req)
+			assert.True(t, req.EnforceAdmins, "Protection of the policy should be sent")
+
+			writeJSON(w, http.StatusOK, &github.Protection{EnforceAdmins: &github.AdminEnforcement{Enabled: req.EnforceAdmins}})
+		})
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate?type=all", `{}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.RemediationReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Empty(t, response.Errors, "Every repository should be evaluated")
+		assert.Len(t, response.Repositories, 1, "Only shared has violations")
+		assert.ElementsMatch(t, []string{models.RulePrivate, models.RuleDefaultBranchProtection}, response.Repositories[0].Fixed, "Both rules should be fixed")
+		assert.Empty(t, response.Repositories[0].Remaining, "Nothing should remain")
+		assert.False(t, fake.called("PATCH /repos/owner/shared"), "A repository of the configured owner should never be touched")
+	})
+
+	t.Run("Keep the protection settings the policy does not model", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		err := app.SetCompliancePolicy(`
+rules:
+  default_branch_protection:
+    enforce_admins: true
+    required_reviews:
+      required_approving_review_count: 2
+    required_status_checks:
+      contexts: [build, lint]
+`)
+		assert.NoError(t, err, "Policy should be valid")
+
+		fake.reply("GET /user/repos", http.StatusOK, []*github.Repository{
+			{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}, DefaultBranch: github.Ptr("main")},
+		})
+		fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusOK, testProtectionWithExtras())
+		fake.handle("PUT /repos/owner/api/branches/main/protection", func(w http.ResponseWriter, r *http.Request) {
+			assertExtrasKept(t, r)
+			writeJSON(w, http.StatusOK, testProtectionWithExtras())
+		})
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate?type=all", `{}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, fake.called("PUT /repos/owner/api/branches/main/protection"), "Protection should be fixed")
+	})
+
+	t.Run("Remediate without a body", func(t *testing.T) {
+		fake, app := newMemberFakeClient(t)
+		fake.reply("PUT /repos/other/shared/branches/main/protection", http.StatusOK, &github.Protection{EnforceAdmins: &github.AdminEnforcement{Enabled: true}})
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate?type=all", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, fake.called("PATCH /repos/other/shared"), "Every covered repository should be remediated")
+	})
+
+	t.Run("Report fixes GitHub refuses", func(t *testing.T) {
+		fake, app := newMemberFakeClient(t)
+		fake.reply("PUT /repos/other/shared/branches/main/protection", http.StatusForbidden, map[string]string{"message": "Must have admin rights to Repository."})
+
+		w := serveJSON(t, app, "POST", "/compliance/remediate?type=all", `{"repositories": ["shared"]}`)
+
+		var response models.RemediationReport
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		result := response.Repositories[0]
+		assert.Equal(t, []string{models.RulePrivate}, result.Fixed, "The repository should still be made private")
+		assert.Equal(t, []models.RuleError{{Rule: models.RuleDefaultBranchProtection, Error: "Must have admin rights to Repository.", Code: models.ErrCodeForbidden}}, result.Failed, "The refused fix should be reported")
+		assert.Len(t, result.Remaining, 1, "The protection violation should remain")
+	})
+}
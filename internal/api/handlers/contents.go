@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// GetContents returns a file with its decoded content, or the entries of a directory
+// '?format=raw' returns the file itself as a download, '?ref=' reads another branch, tag or commit
+func (a *Application) GetContents(c *gin.Context) {
+	repo, filePath := c.Param("repo"), contentPath(c)
+	if err := oneOf("format", c.Query("format"), "", "json", "raw"); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	opts := &github.RepositoryContentGetOptions{Ref: c.Query("ref")}
+	file, dir, _, err := a.githubClient.Repositories.GetContents(ctx, a.owner, repo, filePath, opts)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	if file == nil {
+		respondWithDirectory(c, filePath, dir)
+		return
+	}
+
+	content, err := a.fileContent(ctx, repo, file, opts)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+	respondWithFile(c, file, content)
+}
+
+// UpdateFile creates a file, or replaces it when the blob SHA it was read at is given, in a single commit
+func (a *Application) UpdateFile(c *gin.Context) {
+	repo, filePath := c.Param("repo"), contentPath(c)
+	if filePath == "" {
+		respondWithBadRequest(c, "A file path is required")
+		return
+	}
+
+	var req models.FileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	content, err := decodeContent(req.Content, req.Encoding)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	opts := newFileOptions(req.Message, content, req.SHA, req.Branch, req.Author)
+
+	// Both go to the same GitHub endpoint, which only tells creations and updates apart by the SHA
+	var result *github.RepositoryContentResponse
+	if req.SHA == "" {
+		result, _, err = a.githubClient.Repositories.CreateFile(ctx, a.owner, repo, filePath, opts)
+	} else {
+		result, _, err = a.githubClient.Repositories.UpdateFile(ctx, a.owner, repo, filePath, opts)
+	}
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if req.SHA == "" {
+		status = http.StatusCreated
+	}
+	c.JSON(status, newFileCommitResponse(result, content))
+}
+
+// DeleteFile deletes a file in a single commit, the blob SHA it was read at must be given
+func (a *Application) DeleteFile(c *gin.Context) {
+	repo, filePath := c.Param("repo"), contentPath(c)
+	if filePath == "" {
+		respondWithBadRequest(c, "A file path is required")
+		return
+	}
+
+	var req models.DeleteFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	opts := newFileOptions(req.Message, nil, req.SHA, req.Branch, req.Author)
+	result, _, err := a.githubClient.Repositories.DeleteFile(ctx, a.owner, repo, filePath, opts)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newFileCommitResponse(result, nil))
+}
+
+// fileContent decodes a file, GitHub leaves out the content of files over 1 MB so those are downloaded instead
+func (a *Application) fileContent(ctx context.Context, repo string, file *github.RepositoryContent, opts *github.RepositoryContentGetOptions) ([]byte, error) {
+	if file.GetEncoding() != "none" {
+		content, err := file.GetContent()
+		return []byte(content), err
+	}
+
+	reader, _, err := a.githubClient.Repositories.DownloadContents(ctx, a.owner, repo, file.GetPath(), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// contentPath returns the path addressed by the URL without its surrounding slashes, empty for the repository root
+func contentPath(c *gin.Context) string {
+	return strings.Trim(c.Param("path"), "/")
+}
+
+// decodeContent returns the bytes of a file sent as text, or as base64 with the 'base64' encoding
+func decodeContent(content, encoding string) ([]byte, error) {
+	if encoding != "base64" {
+		return []byte(content), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, errors.New("Content is not valid base64")
+	}
+	return decoded, nil
+}
+
+// newFileOptions builds the commit options of a file write, an empty branch commits to the default branch
+func newFileOptions(message string, content []byte, sha, branch string, author *models.CommitAuthor) *github.RepositoryContentFileOptions {
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.Ptr(message),
+		Content: content,
+	}
+	if sha != "" {
+		opts.SHA = github.Ptr(sha)
+	}
+	if branch != "" {
+		opts.Branch = github.Ptr(branch)
+	}
+	opts.Author = newCommitAuthor(author)
+	return opts
+}
+
+// newCommitAuthor converts the author of a commit, nil lets GitHub use the token owner
+func newCommitAuthor(author *models.CommitAuthor) *github.CommitAuthor {
+	if author == nil {
+		return nil
+	}
+	return &github.CommitAuthor{Name: github.Ptr(author.Name), Email: github.Ptr(author.Email)}
+}
+
+// respondWithFile writes the file as is with '?format=raw', otherwise as JSON with its content
+// Raw files come from the repository, so they are sent as an inert download browsers never render, such as HTML or SVG
+func respondWithFile(c *gin.Context, file *github.RepositoryContent, content []byte) {
+	if c.Query("format") == "raw" {
+		// The file keeps its type but is downloaded, never sniffed and, if opened anyway, runs no script
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Content-Security-Policy", "sandbox")
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.GetName()}))
+		c.Data(http.StatusOK, contentTypeFor(file.GetName(), content), content)
+		return
+	}
+	c.JSON(http.StatusOK, newContentResponse(file, content))
+}
+
+// respondWithDirectory writes the entries of a directory, which has no raw format
+func respondWithDirectory(c *gin.Context, dirPath string, entries []*github.RepositoryContent) {
+	if c.Query("format") == "raw" {
+		respondWithBadRequest(c, fmt.Sprintf("'%s' is a directory, only files can be read raw", dirPath))
+		return
+	}
+	c.JSON(http.StatusOK, newDirectoryResponse(dirPath, entries))
+}
+
+// contentTypeFor guesses the content type from the file extension, then from the content itself
+func contentTypeFor(name string, content []byte) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// newContentResponse converts a file with its decoded content, content that is not text is sent as base64
+func newContentResponse(file *github.RepositoryContent, content []byte) models.ContentResponse {
+	response := models.ContentResponse{
+		Type: file.GetType(),
+		Name: file.GetName(),
+		Path: file.GetPath(),
+		SHA:  file.GetSHA(),
+		Size: file.GetSize(),
+	}
+	if response.Type != "file" {
+		return response
+	}
+
+	response.Encoding, response.Content = "utf-8", string(content)
+	if !utf8.Valid(content) {
+		response.Encoding, response.Content = "base64", base64.StdEncoding.EncodeToString(content)
+	}
+	return response
+}
+
+// newDirectoryResponse converts the listing of a directory, an empty path is the repository root
+func newDirectoryResponse(dirPath string, entries []*github.RepositoryContent) models.ContentResponse {
+	response := models.ContentResponse{Type: "dir", Path: dirPath, Entries: []models.ContentEntry{}}
+	if dirPath != "" {
+		response.Name = path.Base(dirPath)
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, models.ContentEntry{
+			Type: entry.GetType(),
+			Name: entry.GetName(),
+			Path: entry.GetPath(),
+			SHA:  entry.GetSHA(),
+			Size: entry.GetSize(),
+		})
+	}
+	return response
+}
+
+// newFileCommitResponse converts the result of a file write, content is nil when the file was deleted
+func newFileCommitResponse(result *github.RepositoryContentResponse, content []byte) models.FileCommitResponse {
+	response := models.FileCommitResponse{
+		Commit: models.FileCommit{
+			SHA:     result.Commit.GetSHA(),
+			Message: result.Commit.GetMessage(),
+			URL:     result.Commit.GetHTMLURL(),
+		},
+	}
+	if result.Content != nil {
+		file := newContentResponse(result.Content, content)
+		response.Content = &file
+	}
+	return response
+}
@@ -0,0 +1,416 @@
+package handlers_test
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// SHA git gives a blob holding "{\"debug\": false}\n"
+const configSHA = "bd1d9874f79b114a91da87a378ba1d49e9163f00"
+
+// contentsOnGitHub serves the files of the default branch of 'test-repo' on a fake GitHub, keyed by path
+// Large files are served without their content, like GitHub does for files over 1 MB
+type contentsOnGitHub struct {
+	mu    sync.Mutex
+	files map[string]string
+	large map[string]bool
+	sent  github.RepositoryContentFileOptions
+}
+
+func (g *contentsOnGitHub) serve(t *testing.T, fake *fakeGitHub) {
+	fake.handle("GET /repos/owner/test-repo/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		filePath := r.PathValue("path")
+		if content, ok := g.files[filePath]; ok {
+			file := g.file(filePath, content)
+			if g.large[filePath] {
+				file.Encoding, file.Content = github.Ptr("none"), github.Ptr("")
+			}
+			writeJSON(w, http.StatusOK, file)
+			return
+		}
+
+		entries := g.directory(r.Host, filePath)
+		if len(entries) == 0 && filePath != "" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+	})
+	fake.handle("GET /download/test-repo/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		w.Write([]byte(g.files[r.PathValue("path")]))
+	})
+	fake.handle("PUT /repos/owner/test-repo/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.RepositoryContentFileOptions
+		readJSON(t, r, &opts)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.sent = opts
+
+		filePath := r.PathValue("path")
+		current, exists := g.files[filePath]
+		if opts.SHA == nil && exists {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid request.\n\n\"sha\" wasn't supplied."})
+			return
+		}
+		if opts.SHA != nil && (!exists || gitBlobSHA([]byte(current)) != opts.GetSHA()) {
+			writeJSON(w, http.StatusConflict, map[string]string{"message": filePath + " does not match " + opts.GetSHA()})
+			return
+		}
+		g.files[filePath] = string(opts.Content)
+
+		status := http.StatusOK
+		if !exists {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, &github.RepositoryContentResponse{
+			Content: g.file(filePath, string(opts.Content)),
+			Commit:  github.Commit{SHA: github.Ptr(gitBlobSHA([]byte(opts.GetMessage()))), Message: opts.Message},
+		})
+	})
+	fake.handle("DELETE /repos/owner/test-repo/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.RepositoryContentFileOptions
+		readJSON(t, r, &opts)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		filePath := r.PathValue("path")
+		current, exists := g.files[filePath]
+		if !exists {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if gitBlobSHA([]byte(current)) != opts.GetSHA() {
+			writeJSON(w, http.StatusConflict, map[string]string{"message": filePath + " does not match " + opts.GetSHA()})
+			return
+		}
+		delete(g.files, filePath)
+
+		writeJSON(w, http.StatusOK, &github.RepositoryContentResponse{
+			Commit: github.Commit{SHA: github.Ptr(gitBlobSHA([]byte(opts.GetMessage()))), Message: opts.Message},
+		})
+	})
+}
+
+// file builds the file GitHub returns, base64 encoded
+func (g *contentsOnGitHub) file(filePath, content string) *github.RepositoryContent {
+	return &github.RepositoryContent{
+		Type:     github.Ptr("file"),
+		Name:     github.Ptr(path.Base(filePath)),
+		Path:     github.Ptr(filePath),
+		SHA:      github.Ptr(gitBlobSHA([]byte(content))),
+		Size:     github.Ptr(len(content)),
+		Encoding: github.Ptr("base64"),
+		Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte(content))),
+	}
+}
+
+// directory lists the files and subdirectories right under dirPath sorted by path, files link to their download
+func (g *contentsOnGitHub) directory(host, dirPath string) []*github.RepositoryContent {
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + "/"
+	}
+
+	var entries []*github.RepositoryContent
+	for _, filePath := range slices.Sorted(maps.Keys(g.files)) {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+
+		name, _, nested := strings.Cut(strings.TrimPrefix(filePath, prefix), "/")
+		if !nested {
+			file := g.file(filePath, g.files[filePath])
+			file.Encoding, file.Content = nil, nil
+			file.DownloadURL = github.Ptr("http://" + host + "/download/test-repo/" + filePath)
+			entries = append(entries, file)
+			continue
+		}
+		if len(entries) == 0 || entries[len(entries)-1].GetName() != name {
+			entries = append(entries, &github.RepositoryContent{Type: github.Ptr("dir"), Name: github.Ptr(name), Path: github.Ptr(prefix + name)})
+		}
+	}
+	return entries
+}
+
+// gitBlobSHA computes the SHA git gives a blob holding content
+func gitBlobSHA(content []byte) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", len(content))
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func TestContents(t *testing.T) {
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application, *contentsOnGitHub) {
+		contents := &contentsOnGitHub{
+			files: map[string]string{
+				"README.md":          "# Test\n",
+				"config/app.json":    "{\"debug\": false}\n",
+				"config/env/dev.env": "DEBUG=1\n",
+				"logo.png":           "\x89PNG\r\n\x1a\n\x00",
+			},
+		}
+
+		fake, app := newFakeGitHub(t)
+		contents.serve(t, fake)
+		return fake, app, contents
+	}
+
+	t.Run("Read a file", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/config/app.json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.ContentResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "file", response.Type, "Path should be a file")
+		assert.Equal(t, "app.json", response.Name, "Name should match")
+		assert.Equal(t, "utf-8", response.Encoding, "Text should be decoded")
+		assert.Equal(t, "{\"debug\": false}\n", response.Content, "Content should be decoded")
+		assert.NotEmpty(t, response.SHA, "Blob SHA should be returned")
+	})
+
+	t.Run("Read a binary file", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/logo.png", "")
+
+		var response models.ContentResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "base64", response.Encoding, "Binary content should stay base64")
+		assert.Equal(t, "iVBORw0KGgoA", response.Content, "Content should be base64")
+	})
+
+	t.Run("Read a large file", func(t *testing.T) {
+		fake, app, contents := newFakeClient(t)
+		contents.large = map[string]bool{"config/app.json": true}
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/config/app.json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.ContentResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "{\"debug\": false}\n", response.Content, "Content should be downloaded")
+		assert.True(t, fake.called("GET /download/test-repo/config/app.json"), "File should be downloaded")
+	})
+
+	t.Run("Read a raw file", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/config/app.json?format=raw", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "Content type should follow the extension")
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"), "Browsers should not sniff the content")
+		assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"), "File should not run scripts")
+		assert.Equal(t, "attachment; filename=app.json", w.Header().Get("Content-Disposition"), "File should be downloaded")
+		assert.Equal(t, "{\"debug\": false}\n", w.Body.String(), "Raw content should be returned")
+	})
+
+	t.Run("Read a raw HTML file", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+		contents.files["index.html"] = "<script>alert(1)</script>"
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/index.html?format=raw", "")
+
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"), "HTML should keep its type")
+		assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"), "HTML should never run scripts")
+		assert.Equal(t, "attachment; filename=index.html", w.Header().Get("Content-Disposition"), "HTML should be downloaded, not rendered")
+	})
+
+	t.Run("Read a raw binary file", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/logo.png?format=raw", "")
+
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"), "Content type should follow the extension")
+	})
+
+	t.Run("Read a raw file without extension", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+		contents.files["Makefile"] = "build:\n\tgo build ./...\n"
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/Makefile?format=raw", "")
+
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"), "Content type should be detected")
+	})
+
+	t.Run("List a directory", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/config", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.ContentResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "dir", response.Type, "Path should be a directory")
+		assert.Len(t, response.Entries, 2, "config should hold app.json and env")
+		assert.Equal(t, "file", response.Entries[0].Type, "app.json should be a file")
+		assert.Equal(t, "dir", response.Entries[1].Type, "env should be a directory")
+		assert.Empty(t, response.Content, "Directories have no content")
+	})
+
+	t.Run("List the repository root", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/", "")
+
+		var response models.ContentResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response.Entries, 3, "Root should hold README.md, config and logo.png")
+	})
+
+	t.Run("Read a directory raw", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/config?format=raw", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Create a file", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/contents/docs/guide.md", `{"message": "Add guide", "content": "# Guide\n", "branch": "main"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.FileCommitResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "Add guide", response.Commit.Message, "Commit message should match")
+		assert.Equal(t, "docs/guide.md", response.Content.Path, "File path should match")
+		assert.Equal(t, "# Guide\n", contents.files["docs/guide.md"], "File should be created")
+		assert.Equal(t, "main", contents.sent.GetBranch(), "File should be committed to the given branch")
+	})
+
+	t.Run("Create a binary file", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/contents/icon.png", `{"message": "Add icon", "content": "iVBORw0KGgoA", "encoding": "base64"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+		assert.Equal(t, "\x89PNG\r\n\x1a\n\x00", contents.files["icon.png"], "Content should be decoded")
+	})
+
+	t.Run("Update a file at its blob SHA", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+		body := `{"message": "Enable debug", "content": "{\"debug\": true}\n", "sha": "` + configSHA + `", "author": {"name": "Config Bot", "email": "bot@example.com"}}`
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/contents/config/app.json", body)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "{\"debug\": true}\n", contents.files["config/app.json"], "File should be updated")
+		assert.Equal(t, "Config Bot", contents.sent.GetAuthor().GetName(), "Author should be sent")
+	})
+
+	t.Run("Update a file that changed since it was read", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/contents/config/app.json", `{"message": "Enable debug", "content": "{}", "sha": "0000"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+	})
+
+	t.Run("Overwrite a file without its blob SHA", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/contents/README.md", `{"message": "Replace", "content": "x"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Invalid file requests", func(t *testing.T) {
+		tests := []struct {
+			name string
+			url  string
+			body string
+		}{
+			{"Missing message", "/repositories/test-repo/contents/a.txt", `{"content": "x"}`},
+			{"Invalid base64", "/repositories/test-repo/contents/a.txt", `{"message": "Add", "content": "%%%", "encoding": "base64"}`},
+			{"Unknown encoding", "/repositories/test-repo/contents/a.txt", `{"message": "Add", "content": "x", "encoding": "hex"}`},
+			{"Invalid author email", "/repositories/test-repo/contents/a.txt", `{"message": "Add", "author": {"name": "Bot", "email": "bot"}}`},
+			{"No file path", "/repositories/test-repo/contents/", `{"message": "Add"}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Nothing is registered, invalid requests must not reach GitHub
+				_, app := newFakeGitHub(t)
+
+				w := serveJSON(t, app, "PUT", tt.url, tt.body)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+			})
+		}
+	})
+
+	t.Run("Delete a file", func(t *testing.T) {
+		_, app, contents := newFakeClient(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo/contents/config/app.json", `{"message": "Remove config", "sha": "`+configSHA+`"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.FileCommitResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Nil(t, response.Content, "Deleted file should have no content")
+		assert.NotContains(t, contents.files, "config/app.json", "File should be deleted")
+	})
+
+	t.Run("Delete a file without its blob SHA", func(t *testing.T) {
+		// Nothing is registered, invalid requests must not reach GitHub
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo/contents/config/app.json", `{"message": "Remove config"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("File does not exist", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/contents/missing.txt", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-api-service/internal/models"
+)
+
+// How long a delete confirmation token stays valid
+const deleteTokenTTL = 5 * time.Minute
+
+var errInvalidDeleteToken = errors.New("Confirmation token is invalid or expired, or the repository changed since it was issued")
+
+// deleteGuard protects repositories from accidental deletion
+// Deletion needs a short-lived token bound to the repository's current metadata
+// and repositories matching a protected pattern can never be deleted
+type deleteGuard struct {
+	secret    []byte
+	ttl       time.Duration
+	protected []string
+}
+
+// newDeleteGuard creates a guard signing tokens with secret, a random secret is used when empty
+// protected holds glob patterns such as 'prod-*', matched regardless of case like GitHub repository names
+func newDeleteGuard(secret string, protected []string) (*deleteGuard, error) {
+	patterns := make([]string, 0, len(protected))
+	for _, pattern := range protected {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid protected repository pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, strings.ToLower(pattern))
+	}
+
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &deleteGuard{secret: key, ttl: deleteTokenTTL, protected: patterns}, nil
+}
+
+// parseProtectedRepos splits the comma separated PROTECTED_REPOS value into patterns
+func parseProtectedRepos(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// isProtected reports whether the repository matches one of the protected patterns
+// GitHub resolves 'PROD-api' to 'prod-api', so names are compared in lower case
+func (g *deleteGuard) isProtected(repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, pattern := range g.protected {
+		if matched, _ := path.Match(pattern, repo); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// issueToken creates a confirmation token for deleting repo in its current state
+func (g *deleteGuard) issueToken(repo string, meta models.RepoDeleteMetadata, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(g.ttl).Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	token := base64.RawURLEncoding.EncodeToString([]byte(expiry)) + "." +
+		base64.RawURLEncoding.EncodeToString(g.sign(repo, meta, expiry))
+	return token, expiresAt
+}
+
+// verifyToken checks the token was issued for repo in the same state and has not expired
+func (g *deleteGuard) verifyToken(token string, repo string, meta models.RepoDeleteMetadata, now time.Time) error {
+	encodedExpiry, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return errInvalidDeleteToken
+	}
+
+	expiry, err := base64.RawURLEncoding.DecodeString(encodedExpiry)
+	if err != nil {
+		return errInvalidDeleteToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return errInvalidDeleteToken
+	}
+
+	expiresAt, err := strconv.ParseInt(string(expiry), 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return errInvalidDeleteToken
+	}
+
+	if !hmac.Equal(signature, g.sign(repo, meta, string(expiry))) {
+		return errInvalidDeleteToken
+	}
+	return nil
+}
+
+// sign binds the repository name, its metadata and the expiry together
+func (g *deleteGuard) sign(repo string, meta models.RepoDeleteMetadata, expiry string) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	fmt.Fprintf(mac, "%s|%d|%d|%d|%s", repo, meta.Stars, meta.PushedAt.Unix(), meta.OpenPullRequests, expiry)
+	return mac.Sum(nil)
+}
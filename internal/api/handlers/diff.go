@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// Content types of the raw pull request downloads
+const (
+	diffContentType  = "text/x-diff; charset=utf-8"
+	patchContentType = "text/x-patch; charset=utf-8"
+)
+
+var (
+	hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+	// Every message of a patch starts with this line, the date is fixed so it cannot be confused with an email
+	patchMessagePattern = regexp.MustCompile(`^From ([0-9a-f]{40}) Mon Sep 17 00:00:00 2001$`)
+	patchSubjectPrefix  = regexp.MustCompile(`^\[PATCH[^\]]*\] *`)
+)
+
+// GetPullRequestDiff returns the unified diff of a pull request, or its files and hunks with '?format=json'
+func (a *Application) GetPullRequestDiff(c *gin.Context) {
+	a.getRawPullRequest(c, github.Diff)
+}
+
+// GetPullRequestPatch returns a pull request as an mbox patch with one message per commit
+// or each commit with the files and hunks it changes with '?format=json'
+func (a *Application) GetPullRequestPatch(c *gin.Context) {
+	a.getRawPullRequest(c, github.Patch)
+}
+
+// getRawPullRequest fetches the pull request in the raw format and responds with it
+func (a *Application) getRawPullRequest(c *gin.Context, rawType github.RawType) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	if err := oneOf("format", c.Query("format"), "", "raw", "json"); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	raw, _, err := a.githubClient.PullRequests.GetRaw(ctx, a.owner, repo, number, github.RawOptions{Type: rawType})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	respondWithDiff(c, raw, rawType)
+}
+
+// respondWithDiff writes the raw diff or patch as is, or parsed when the client asked for JSON
+// A diff is parsed into files and hunks, a patch into commits so files changed by several commits are not mixed up
+func respondWithDiff(c *gin.Context, raw string, rawType github.RawType) {
+	if c.Query("format") != "json" {
+		contentType := diffContentType
+		if rawType == github.Patch {
+			contentType = patchContentType
+		}
+		c.Data(http.StatusOK, contentType, []byte(raw))
+		return
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+	if rawType == github.Patch {
+		parsed, err = parsePatch(raw)
+	} else {
+		parsed, err = parseUnifiedDiff(raw)
+	}
+	if err != nil {
+		respondWithError(c, http.StatusBadGateway, models.ErrCodeUpstreamError, fmt.Sprintf("Failed to parse the diff returned by GitHub: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, parsed)
+}
+
+// parsePatch splits an mbox patch into its commits, each with its subject and the files it changes
+func parsePatch(patch string) ([]models.PatchCommit, error) {
+	commits := []models.PatchCommit{}
+	lines := strings.Split(patch, "\n")
+
+	// Messages run from their 'From <sha>' line to the next one
+	var starts []int
+	for i, line := range lines {
+		if patchMessagePattern.MatchString(line) {
+			starts = append(starts, i)
+		}
+	}
+
+	for n, start := range starts {
+		end := len(lines)
+		if n+1 < len(starts) {
+			end = starts[n+1]
+		}
+		message := lines[start+1 : end]
+
+		files, err := parseUnifiedDiff(strings.Join(message, "\n"))
+		if err != nil {
+			return nil, err
+		}
+
+		sha := patchMessagePattern.FindStringSubmatch(lines[start])[1]
+		commits = append(commits, models.PatchCommit{SHA: sha, Subject: patchSubject(message), Files: files})
+	}
+
+	return commits, nil
+}
+
+// patchSubject reads the Subject header of a patch message without its '[PATCH n/m]' prefix
+// Long subjects are folded over several lines and subjects that are not ASCII are MIME encoded
+func patchSubject(message []string) string {
+	subject, inSubject := "", false
+	for _, line := range message {
+		if line == "" {
+			// Headers end at the first empty line
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "Subject: "):
+			subject, inSubject = strings.TrimPrefix(line, "Subject: "), true
+		case inSubject && (line[0] == ' ' || line[0] == '\t'):
+			subject += " " + strings.TrimSpace(line)
+		default:
+			inSubject = false
+		}
+	}
+
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	return patchSubjectPrefix.ReplaceAllString(subject, "")
+}
+
+// parseUnifiedDiff splits a git unified diff into files and hunks
+// Anything before the first 'diff --git' line, such as the mbox headers of a patch, is ignored
+func parseUnifiedDiff(diff string) ([]models.DiffFile, error) {
+	files := []models.DiffFile{}
+
+	var (
+		file                       *models.DiffFile
+		hunk                       *models.DiffHunk
+		oldLine, newLine           int
+		oldRemaining, newRemaining int
+	)
+
+	for _, line := range strings.Split(diff, "\n") {
+		// Hunk lines are consumed by count so content starting with '---' or 'diff' is not mistaken for a header
+		if hunk != nil && (oldRemaining > 0 || newRemaining > 0) {
+			if line == "" {
+				// Some tools strip the leading space of empty context lines
+				line = " "
+			}
+
+			diffLine := models.DiffLine{Content: line[1:]}
+			switch line[0] {
+			case ' ':
+				diffLine.Type = "context"
+				diffLine.OldLine, diffLine.NewLine = oldLine, newLine
+				oldLine, newLine = oldLine+1, newLine+1
+				oldRemaining, newRemaining = oldRemaining-1, newRemaining-1
+			case '-':
+				diffLine.Type = "delete"
+				diffLine.OldLine = oldLine
+				oldLine, oldRemaining = oldLine+1, oldRemaining-1
+			case '+':
+				diffLine.Type = "add"
+				diffLine.NewLine = newLine
+				newLine, newRemaining = newLine+1, newRemaining-1
+			case '\\':
+				// '\ No newline at end of file' annotates the previous line
+				continue
+			default:
+				return nil, fmt.Errorf("unexpected line in hunk %q: %q", hunk.Header, line)
+			}
+			hunk.Lines = append(hunk.Lines, diffLine)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldPath, newPath := splitGitDiffPaths(strings.TrimPrefix(line, "diff --git "))
+			files = append(files, models.DiffFile{OldPath: oldPath, NewPath: newPath, Status: "modified", Hunks: []models.DiffHunk{}})
+			file, hunk = &files[len(files)-1], nil
+		case file == nil:
+			// Preamble before the first file
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status, file.OldPath = "added", ""
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status, file.NewPath = "removed", ""
+		case strings.HasPrefix(line, "rename from "):
+			file.Status, file.OldPath = "renamed", strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.Status, file.NewPath = "renamed", strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			file.Binary = true
+		case strings.HasPrefix(line, "--- "):
+			if path := diffPath(strings.TrimPrefix(line, "--- "), "a/"); path != "" {
+				file.OldPath = path
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if path := diffPath(strings.TrimPrefix(line, "+++ "), "b/"); path != "" {
+				file.NewPath = path
+			}
+		case strings.HasPrefix(line, "@@ "):
+			parsed, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, parsed)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldLine, newLine = parsed.OldStart, parsed.NewStart
+			oldRemaining, newRemaining = parsed.OldLines, parsed.NewLines
+		}
+	}
+
+	return files, nil
+}
+
+// parseHunkHeader reads the line ranges of a '@@ -1,3 +1,4 @@ section' header, omitted counts default to 1
+func parseHunkHeader(line string) (models.DiffHunk, error) {
+	match := hunkHeaderPattern.FindStringSubmatch(line)
+	if match == nil {
+		return models.DiffHunk{}, fmt.Errorf("invalid hunk header: %q", line)
+	}
+
+	number := func(value string) int {
+		if value == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+
+	return models.DiffHunk{
+		Header:   line,
+		OldStart: number(match[1]),
+		OldLines: number(match[2]),
+		NewStart: number(match[3]),
+		NewLines: number(match[4]),
+		Section:  match[5],
+		Lines:    []models.DiffLine{},
+	}, nil
+}
+
+// splitGitDiffPaths splits 'a/old b/new' from a 'diff --git' line
+// Paths may contain ' b/', so the split where both sides match is preferred
+func splitGitDiffPaths(paths string) (string, string) {
+	oldPath, newPath := "", ""
+	for i := strings.Index(paths, " b/"); i >= 0; {
+		left, right := strings.TrimPrefix(paths[:i], "a/"), paths[i+len(" b/"):]
+		if oldPath == "" || left == right {
+			oldPath, newPath = left, right
+		}
+		if left == right {
+			break
+		}
+
+		next := strings.Index(paths[i+1:], " b/")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return oldPath, newPath
+}
+
+// diffPath strips the 'a/' or 'b/' prefix from a '---' or '+++' path, '/dev/null' gives an empty path
+func diffPath(path, prefix string) string {
+	// Git appends a tab when the path contains spaces
+	path = strings.TrimSuffix(path, "\t")
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
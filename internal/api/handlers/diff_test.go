@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@ package main
+ import "fmt"
+-func old() {}
++func added() {}
++--- not a header
+ 
+ func main() {
+@@ -10 +11 @@ func main() {
+-	fmt.Println("a")
++	fmt.Println("b")
+\ No newline at end of file
+diff --git a/docs/new file.md b/docs/new file.md
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/docs/new file.md	
+@@ -0,0 +1 @@
++# Docs
+diff --git a/old.txt b/renamed.txt
+similarity index 100%
+rename from old.txt
+rename to renamed.txt
+diff --git a/logo.png b/logo.png
+deleted file mode 100644
+index e69de29..0000000
+Binary files a/logo.png and /dev/null differ
+`
+
+// testPatch holds two commits that both change main.go
+const testPatch = `From 6dcb09b5b57875f334f61aebed695e2e4193db5e Mon Sep 17 00:00:00 2001
+From: Octocat <octocat@github.com>
+Date: Mon, 1 Apr 2024 10:00:00 +0000
+Subject: [PATCH 1/2] Change
+
+---
+ main.go | 2 +-
+` + testDiff + `-- 
+2.44.0
+
+From 7fd1a60b01f91b314f59955a4e4d4e80d8edf11d Mon Sep 17 00:00:00 2001
+From: Octocat <octocat@github.com>
+Date: Tue, 2 Apr 2024 10:00:00 +0000
+Subject: [PATCH 2/2] =?UTF-8?q?Corrige=20l'=C3=A9criture?= of a very long
+ subject
+
+Body mentioning diff --git in prose
+---
+ main.go | 2 +-
+diff --git a/main.go b/main.go
+index bf269f4..c3a1e2d 100644
+--- a/main.go
++++ b/main.go
+@@ -11 +11 @@ func main() {
+-	fmt.Println("b")
++	fmt.Println("c")
+-- 
+2.44.0
+`
+
+func TestPullRequestDiff(t *testing.T) {
+	// newFakeClient serves PR #1 of 'test-repo' as diff or patch depending on the media type asked for
+	newFakeClient := func(t *testing.T, diff string) *handlers.Application {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /repos/owner/test-repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Header.Get("Accept") {
+			case "application/vnd.github.v3.diff":
+				w.Write([]byte(diff))
+			case "application/vnd.github.v3.patch":
+				w.Write([]byte(testPatch))
+			default:
+				t.Errorf("Unexpected media type %q", r.Header.Get("Accept"))
+			}
+		})
+		return app
+	}
+
+	t.Run("Raw diff and patch", func(t *testing.T) {
+		app := newFakeClient(t, testDiff)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/diff", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "text/x-diff; charset=utf-8", w.Header().Get("Content-Type"), "Content type should be a diff")
+		assert.Equal(t, testDiff, w.Body.String(), "Diff should be returned as is")
+
+		w = serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/patch", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "text/x-patch; charset=utf-8", w.Header().Get("Content-Type"), "Content type should be a patch")
+	})
+
+	t.Run("Diff parsed into hunks", func(t *testing.T) {
+		w := serveJSON(t, newFakeClient(t, testDiff), "GET", "/repositories/test-repo/pull-requests/1/diff?format=json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var files []models.DiffFile
+		err := json.Unmarshal(w.Body.Bytes(), &files)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, files, 4, "There should be 4 files")
+
+		main := files[0]
+		assert.Equal(t, "modified", main.Status, "main.go should be modified")
+		assert.Len(t, main.Hunks, 2, "main.go should have 2 hunks")
+		assert.Equal(t, "package main", main.Hunks[0].Section, "Hunk section should match")
+		assert.Equal(t, []models.DiffLine{
+			{Type: "context", Content: `import "fmt"`, OldLine: 1, NewLine: 1},
+			{Type: "delete", Content: "func old() {}", OldLine: 2},
+			{Type: "add", Content: "func added() {}", NewLine: 2},
+			{Type: "add", Content: "--- not a header", NewLine: 3},
+			{Type: "context", Content: "", OldLine: 3, NewLine: 4},
+			{Type: "context", Content: "func main() {", OldLine: 4, NewLine: 5},
+		}, main.Hunks[0].Lines, "First hunk lines should match")
+		assert.Equal(t, 1, main.Hunks[1].OldLines, "Omitted line count should default to 1")
+		assert.Len(t, main.Hunks[1].Lines, 2, "No newline marker should be skipped")
+
+		assert.Equal(t, models.DiffFile{Status: "added", NewPath: "docs/new file.md", Hunks: []models.DiffHunk{{
+			Header: "@@ -0,0 +1 @@", OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1,
+			Lines: []models.DiffLine{{Type: "add", Content: "# Docs", NewLine: 1}},
+		}}}, files[1], "Added file should match")
+
+		assert.Equal(t, "renamed", files[2].Status, "File should be renamed")
+		assert.Equal(t, "old.txt", files[2].OldPath, "Old path should match")
+		assert.Equal(t, "renamed.txt", files[2].NewPath, "New path should match")
+
+		assert.Equal(t, "removed", files[3].Status, "Binary file should be removed")
+		assert.True(t, files[3].Binary, "File should be binary")
+	})
+
+	t.Run("Patch parsed into hunks", func(t *testing.T) {
+		w := serveJSON(t, newFakeClient(t, testDiff), "GET", "/repositories/test-repo/pull-requests/1/patch?format=json", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var commits []models.PatchCommit
+		err := json.Unmarshal(w.Body.Bytes(), &commits)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, commits, 2, "Every commit should be listed")
+		assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", commits[0].SHA, "SHA should come from the mbox header")
+		assert.Equal(t, "Change", commits[0].Subject, "Subject should not keep the [PATCH] prefix")
+		assert.Len(t, commits[0].Files, 4, "Mbox headers and signature should be skipped")
+		assert.Equal(t, "Corrige l'écriture of a very long subject", commits[1].Subject, "Subject should be unfolded and decoded")
+		assert.Len(t, commits[1].Files, 1, "Files of other commits should not be merged in")
+		assert.Equal(t, "\tfmt.Println(\"c\")", commits[1].Files[0].Hunks[0].Lines[1].Content, "Hunk should belong to its commit")
+	})
+
+	t.Run("Malformed diff", func(t *testing.T) {
+		app := newFakeClient(t, "diff --git a/x b/x\n@@ broken @@\n")
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/diff?format=json", "")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		// Nothing is registered, the format is checked before calling GitHub
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/diff?format=html", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Pull request does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo/pulls/2", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/2/diff", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
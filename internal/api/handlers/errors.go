@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// respondWithError writes an error body using the shared error model
+func respondWithError(c *gin.Context, status int, code string, message string) {
+	c.JSON(status, models.ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
+}
+
+// respondWithBadRequest reports an invalid client request
+func respondWithBadRequest(c *gin.Context, message string) {
+	respondWithError(c, http.StatusBadRequest, models.ErrCodeBadRequest, message)
+}
+
+// respondWithGitHubError translates an error returned by go-github into the matching
+// HTTP status and error body
+func respondWithGitHubError(c *gin.Context, err error) {
+	status, body := translateGitHubError(err)
+	if body.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(body.RetryAfter))
+	}
+	c.JSON(status, body)
+}
+
+// translateGitHubError maps rate limits, GitHub error responses and transport failures
+// to a status code and error body
+func translateGitHubError(err error) (int, models.ErrorResponse) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return http.StatusTooManyRequests, models.ErrorResponse{
+			Error:      messageOrDefault(rateLimitErr.Message, "API rate limit exceeded"),
+			Code:       models.ErrCodeRateLimited,
+			RequestID:  requestID(rateLimitErr.Response),
+			RetryAfter: secondsUntil(rateLimitErr.Rate.Reset.Time),
+		}
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		body := models.ErrorResponse{
+			Error:     messageOrDefault(abuseErr.Message, "Secondary rate limit exceeded"),
+			Code:      models.ErrCodeRateLimited,
+			RequestID: requestID(abuseErr.Response),
+		}
+		if abuseErr.RetryAfter != nil {
+			body.RetryAfter = int(math.Ceil(abuseErr.RetryAfter.Seconds()))
+		}
+		return http.StatusTooManyRequests, body
+	}
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		status, code := http.StatusBadGateway, models.ErrCodeUpstreamError
+		if errResp.Response != nil {
+			status, code = statusForGitHubStatus(errResp.Response.StatusCode)
+		}
+
+		body := models.ErrorResponse{
+			Error:     messageOrDefault(errResp.Message, http.StatusText(status)),
+			Code:      code,
+			RequestID: requestID(errResp.Response),
+		}
+		for _, e := range errResp.Errors {
+			body.Errors = append(body.Errors, models.FieldError{
+				Resource: e.Resource,
+				Field:    e.Field,
+				Code:     e.Code,
+				Message:  e.Message,
+			})
+		}
+		return status, body
+	}
+
+	var graphQLErr *GraphQLError
+	if errors.As(err, &graphQLErr) {
+		status, code := graphQLErr.status()
+		return status, models.ErrorResponse{Error: graphQLErr.Message, Code: code}
+	}
+
+	// Anything else means GitHub could not be reached or answered with something unusable
+	return http.StatusBadGateway, models.ErrorResponse{
+		Error: err.Error(),
+		Code:  models.ErrCodeUpstreamError,
+	}
+}
+
+// statusForGitHubStatus picks the status this service returns for a GitHub error status
+func statusForGitHubStatus(status int) (int, string) {
+	switch {
+	case status == http.StatusUnauthorized:
+		return http.StatusUnauthorized, models.ErrCodeUnauthorized
+	case status == http.StatusForbidden:
+		return http.StatusForbidden, models.ErrCodeForbidden
+	case status == http.StatusNotFound:
+		return http.StatusNotFound, models.ErrCodeNotFound
+	case status == http.StatusConflict:
+		return http.StatusConflict, models.ErrCodeConflict
+	case status == http.StatusUnprocessableEntity:
+		return http.StatusUnprocessableEntity, models.ErrCodeValidationFailed
+	case status == http.StatusTooManyRequests:
+		return http.StatusTooManyRequests, models.ErrCodeRateLimited
+	case status >= 400 && status < 500:
+		return http.StatusBadRequest, models.ErrCodeBadRequest
+	default:
+		return http.StatusBadGateway, models.ErrCodeUpstreamError
+	}
+}
+
+// requestID returns the GitHub request ID of a response so failures can be traced
+func requestID(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	return resp.Header.Get("X-GitHub-Request-Id")
+}
+
+// secondsUntil rounds the time left until t up to whole seconds
+func secondsUntil(t time.Time) int {
+	seconds := int(math.Ceil(time.Until(t).Seconds()))
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// messageOrDefault falls back to a generic message when GitHub did not send one
+func messageOrDefault(message, fallback string) string {
+	if message == "" {
+		return fallback
+	}
+	return message
+}
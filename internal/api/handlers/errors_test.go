@@ -0,0 +1,209 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/api/routes"
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// githubResponse builds the HTTP response GitHub would have attached to an error
+func githubResponse(status int) *http.Response {
+	header := http.Header{}
+	header.Set("X-GitHub-Request-Id", "ABCD:1234")
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Request:    &http.Request{Method: "GET", URL: &url.URL{Path: "/user/repos"}},
+	}
+}
+
+// listWithError performs a repository listing against a mock failing with mockErr
+func listWithError(t *testing.T, mockErr error) (*httptest.ResponseRecorder, models.ErrorResponse) {
+	mockClient := &handlers.GitHubMock{MockError: mockErr}
+
+	gin.SetMode(gin.TestMode)
+
+	r := gin.Default()
+	ghClient := handlers.GetClientForTest(mockClient)
+	routes.SetupRoutes(r, *ghClient)
+
+	req, err := http.NewRequest("GET", "/repositories", nil)
+	assert.NoError(t, err, errRequestCreate)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response models.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err, errJSONUnmarshal)
+
+	return w, response
+}
+
+func TestGitHubErrorTranslation(t *testing.T) {
+	t.Run("Not found", func(t *testing.T) {
+		w, response := listWithError(t, &github.ErrorResponse{
+			Response: githubResponse(http.StatusNotFound),
+			Message:  "Not Found",
+		})
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+		assert.Equal(t, models.ErrCodeNotFound, response.Code, "Error code should be 'not_found'")
+		assert.Equal(t, "Not Found", response.Error, "Error message should match GitHub's")
+		assert.Equal(t, "ABCD:1234", response.RequestID, "GitHub request ID should be forwarded")
+	})
+
+	t.Run("Bad credentials", func(t *testing.T) {
+		w, response := listWithError(t, &github.ErrorResponse{
+			Response: githubResponse(http.StatusUnauthorized),
+			Message:  "Bad credentials",
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Code should be 401 Unauthorized")
+		assert.Equal(t, models.ErrCodeUnauthorized, response.Code, "Error code should be 'unauthorized'")
+	})
+
+	t.Run("Validation failed", func(t *testing.T) {
+		w, response := listWithError(t, &github.ErrorResponse{
+			Response: githubResponse(http.StatusUnprocessableEntity),
+			Message:  "Repository creation failed.",
+			Errors: []github.Error{
+				{Resource: "Repository", Field: "name", Code: "custom", Message: "name already exists on this account"},
+			},
+		})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+		assert.Equal(t, models.ErrCodeValidationFailed, response.Code, "Error code should be 'validation_failed'")
+		assert.Len(t, response.Errors, 1, "Field errors should be forwarded")
+		assert.Equal(t, "name", response.Errors[0].Field, "Field error should reference 'name'")
+	})
+
+	t.Run("GraphQL error", func(t *testing.T) {
+		w, response := listWithError(t, &handlers.GraphQLError{Type: "NOT_FOUND", Message: "Could not resolve to a node"})
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+		assert.Equal(t, models.ErrCodeNotFound, response.Code, "Error code should be 'not_found'")
+		assert.Equal(t, "Could not resolve to a node", response.Error, "Error message should match GitHub's")
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		w, response := listWithError(t, &github.RateLimitError{
+			Rate:     github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}},
+			Response: githubResponse(http.StatusForbidden),
+			Message:  "API rate limit exceeded",
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Code should be 429 TooManyRequests")
+		assert.Equal(t, models.ErrCodeRateLimited, response.Code, "Error code should be 'rate_limited'")
+		assert.InDelta(t, 60, response.RetryAfter, 2, "Retry after should match the rate limit reset")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"), "Retry-After header should be set")
+	})
+
+	t.Run("Secondary rate limit", func(t *testing.T) {
+		retryAfter := 30 * time.Second
+		w, response := listWithError(t, &github.AbuseRateLimitError{
+			Response:   githubResponse(http.StatusForbidden),
+			Message:    "You have exceeded a secondary rate limit",
+			RetryAfter: &retryAfter,
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Code should be 429 TooManyRequests")
+		assert.Equal(t, 30, response.RetryAfter, "Retry after should be 30 seconds")
+		assert.Equal(t, "30", w.Header().Get("Retry-After"), "Retry-After header should be 30")
+	})
+
+	t.Run("GitHub outage", func(t *testing.T) {
+		w, response := listWithError(t, &github.ErrorResponse{
+			Response: githubResponse(http.StatusServiceUnavailable),
+		})
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+		assert.Equal(t, models.ErrCodeUpstreamError, response.Code, "Error code should be 'upstream_error'")
+	})
+
+	t.Run("Transport error", func(t *testing.T) {
+		w, response := listWithError(t, &url.Error{Op: "Get", URL: "https://api.github.com/user/repos", Err: http.ErrHandlerTimeout})
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+		assert.Equal(t, models.ErrCodeUpstreamError, response.Code, "Error code should be 'upstream_error'")
+	})
+}
+
+func TestGitHubErrorTranslationOnGitHub(t *testing.T) {
+	listRepos := func(t *testing.T, app *handlers.Application) (*httptest.ResponseRecorder, models.ErrorResponse) {
+		w := serveJSON(t, app, "GET", "/repositories", "")
+
+		var response models.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+		return w, response
+	}
+
+	t.Run("Not found", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		})
+
+		w, response := listRepos(t, app)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+		assert.Equal(t, models.ErrCodeNotFound, response.Code, "Error code should be 'not_found'")
+		assert.Equal(t, "Not Found", response.Error, "Error message should match GitHub's")
+		assert.Equal(t, "ABCD:1234", response.RequestID, "GitHub request ID should be forwarded")
+	})
+
+	t.Run("Validation failed", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /user/repos", http.StatusUnprocessableEntity, map[string]any{
+			"message": "Validation Failed",
+			"errors":  []map[string]string{{"resource": "Repository", "field": "visibility", "code": "invalid"}},
+		})
+
+		w, response := listRepos(t, app)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+		assert.Equal(t, models.ErrCodeValidationFailed, response.Code, "Error code should be 'validation_failed'")
+		assert.Len(t, response.Errors, 1, "Field errors should be forwarded")
+		assert.Equal(t, "visibility", response.Errors[0].Field, "Field error should reference 'visibility'")
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+			writeJSON(w, http.StatusForbidden, map[string]string{"message": "API rate limit exceeded"})
+		})
+
+		w, response := listRepos(t, app)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Code should be 429 TooManyRequests")
+		assert.Equal(t, models.ErrCodeRateLimited, response.Code, "Error code should be 'rate_limited'")
+		assert.InDelta(t, 60, response.RetryAfter, 2, "Retry after should match the rate limit reset")
+	})
+
+	t.Run("GitHub outage", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /user/repos", http.StatusServiceUnavailable, map[string]string{"message": "Service Unavailable"})
+
+		w, response := listRepos(t, app)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+		assert.Equal(t, models.ErrCodeUpstreamError, response.Code, "Error code should be 'upstream_error'")
+	})
+}
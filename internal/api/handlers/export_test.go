@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/url"
+
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// NewTestApplication returns an Application calling the GitHub API served at baseURL
+// Repositories are backed up into backupDir before being deleted
+func NewTestApplication(baseURL, owner, backupDir string) (*Application, error) {
+	client := github.NewClient(nil)
+	endpoint, err := url.Parse(baseURL + "/")
+	if err != nil {
+		return nil, err
+	}
+	client.BaseURL = endpoint
+
+	guard, err := newDeleteGuard("test-secret", nil)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := newBackupStore(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Application{
+		githubClient: client,
+		owner:        owner,
+		maxResults:   defaultMaxResults,
+		deleteGuard:  guard,
+		backups:      backups,
+		metricsCache: newTTLCache[models.PullRequestMetrics](defaultMetricsCacheTTL),
+	}, nil
+}
+
+// SetCompliancePolicy configures the compliance policy from its YAML text
+func (a *Application) SetCompliancePolicy(policy string) error {
+	compliance, err := parseCompliancePolicy([]byte(policy))
+	a.compliance = compliance
+	return err
+}
+
+// BackupDir returns the directory repositories are backed up into
+func (a *Application) BackupDir() string {
+	return a.backups.dir
+}
+
+// SetMaxResults caps how many items are fetched when walking every page
+func (a *Application) SetMaxResults(maxResults int) {
+	a.maxResults = maxResults
+}
+
+// SetProtectedRepos configures the patterns of repositories that can never be deleted
+func (a *Application) SetProtectedRepos(patterns ...string) error {
+	guard, err := newDeleteGuard("test-secret", patterns)
+	a.deleteGuard = guard
+	return err
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import "sync"
+
+// Calls made at once when fanning out over repositories or pull requests
+// Kept low so a large account does not trip GitHub's secondary rate limits
+const fanOutConcurrency = 8
+
+// fanOut calls fn on every item with at most limit calls in flight
+// Results and errors are indexed like items
+func fanOut[T, R any](items []T, limit int, fn func(T) (R, error)) ([]R, []error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = fn(item)
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
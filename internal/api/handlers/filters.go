@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// repoFilter holds the repository listing options
+// Type, visibility, sort and direction are applied by GitHub, the rest in-process
+type repoFilter struct {
+	Type       string
+	Visibility string
+	Sort       string
+	Direction  string
+	Name       string
+	Topic      string
+	Language   string
+	Archived   *bool
+}
+
+// Affiliations matching each repository type, used when a visibility is requested
+// because GitHub rejects type combined with visibility
+var repoTypeAffiliations = map[string]string{
+	"owner":  "owner",
+	"member": "collaborator,organization_member",
+	"all":    "owner,collaborator,organization_member",
+}
+
+// parseRepoFilter reads and validates the repository listing query parameters
+func parseRepoFilter(c *gin.Context) (repoFilter, error) {
+	filter := repoFilter{
+		Type:       c.DefaultQuery("type", "owner"),
+		Visibility: c.Query("visibility"),
+		Sort:       c.DefaultQuery("sort", "full_name"),
+		Direction:  c.Query("direction"),
+		Name:       c.Query("name"),
+		Topic:      c.Query("topic"),
+		Language:   c.Query("language"),
+	}
+
+	if err := oneOf("type", filter.Type, "owner", "member", "all"); err != nil {
+		return repoFilter{}, err
+	}
+	if err := oneOf("visibility", filter.Visibility, "", "all", "public", "private"); err != nil {
+		return repoFilter{}, err
+	}
+	if err := oneOf("sort", filter.Sort, "created", "updated", "pushed", "full_name"); err != nil {
+		return repoFilter{}, err
+	}
+	if err := oneOf("direction", filter.Direction, "", "asc", "desc"); err != nil {
+		return repoFilter{}, err
+	}
+
+	archived, err := parseOptionalBool(c, "archived")
+	if err != nil {
+		return repoFilter{}, err
+	}
+	filter.Archived = archived
+
+	return filter, nil
+}
+
+// listOptions converts the filter into the options GitHub applies server-side
+func (f repoFilter) listOptions(listOpts github.ListOptions) *github.RepositoryListByAuthenticatedUserOptions {
+	opts := &github.RepositoryListByAuthenticatedUserOptions{
+		ListOptions: listOpts,
+		Sort:        f.Sort,
+		Direction:   f.Direction,
+	}
+
+	if f.Visibility == "" {
+		opts.Type = f.Type
+	} else {
+		opts.Visibility = f.Visibility
+		opts.Affiliation = repoTypeAffiliations[f.Type]
+	}
+	return opts
+}
+
+// matches reports whether a repository passes the in-process filters
+func (f repoFilter) matches(repo *github.Repository) bool {
+	if f.Archived != nil && repo.GetArchived() != *f.Archived {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(repo.GetName()), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.Language != "" && !strings.EqualFold(repo.GetLanguage(), f.Language) {
+		return false
+	}
+	if f.Topic != "" && !containsFold(repo.Topics, f.Topic) {
+		return false
+	}
+	return true
+}
+
+// apply keeps the repositories passing the in-process filters
+func (f repoFilter) apply(repos []*github.Repository) []*github.Repository {
+	filtered := make([]*github.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if f.matches(repo) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered
+}
+
+// ownerOf returns the account owning a listed repository
+// Listings filtered on 'member' or 'all' hold repositories of other accounts, which must not be read as the configured owner's
+func (a *Application) ownerOf(repo *github.Repository) string {
+	if login := repo.GetOwner().GetLogin(); login != "" {
+		return login
+	}
+	return a.owner
+}
+
+// oneOf checks that a query parameter holds one of the allowed values
+func oneOf(name, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid %s parameter", name)
+}
+
+// containsFold reports whether values contains target ignoring case
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// prFilter holds the pull request listing options
+// State, base, head, sort and direction are applied by GitHub, the rest in-process
+type prFilter struct {
+	State        string
+	Author       string
+	Base         string
+	Head         string
+	Label        string
+	Draft        *bool
+	CreatedSince time.Time
+	CreatedUntil time.Time
+	UpdatedSince time.Time
+	UpdatedUntil time.Time
+	Sort         string
+	Direction    string
+}
+
+// parsePRFilter reads and validates the pull request listing query parameters
+func parsePRFilter(c *gin.Context) (prFilter, error) {
+	filter := prFilter{
+		State:     c.DefaultQuery("state", "open"),
+		Author:    c.Query("author"),
+		Base:      c.Query("base"),
+		Head:      c.Query("head"),
+		Label:     c.Query("label"),
+		Sort:      c.DefaultQuery("sort", "created"),
+		Direction: c.Query("direction"),
+	}
+
+	if err := oneOf("state", filter.State, "open", "closed", "merged", "all"); err != nil {
+		return prFilter{}, err
+	}
+	if err := oneOf("sort", filter.Sort, "created", "updated", "popularity", "long-running"); err != nil {
+		return prFilter{}, err
+	}
+	if err := oneOf("direction", filter.Direction, "", "asc", "desc"); err != nil {
+		return prFilter{}, err
+	}
+
+	draft, err := parseOptionalBool(c, "draft")
+	if err != nil {
+		return prFilter{}, err
+	}
+	filter.Draft = draft
+
+	dates := []struct {
+		name   string
+		target *time.Time
+	}{
+		{"created_since", &filter.CreatedSince},
+		{"created_until", &filter.CreatedUntil},
+		{"updated_since", &filter.UpdatedSince},
+		{"updated_until", &filter.UpdatedUntil},
+	}
+	for _, date := range dates {
+		if *date.target, err = parseQueryTime(c, date.name); err != nil {
+			return prFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+// listOptions converts the filter into the options GitHub applies server-side
+// GitHub has no merged state so merged pull requests are picked among the closed ones
+func (f prFilter) listOptions(owner string, listOpts github.ListOptions) *github.PullRequestListOptions {
+	opts := &github.PullRequestListOptions{
+		State:       f.State,
+		Base:        f.Base,
+		Head:        f.Head,
+		Sort:        f.Sort,
+		Direction:   f.Direction,
+		ListOptions: listOpts,
+	}
+
+	if f.State == "merged" {
+		opts.State = "closed"
+	}
+	// GitHub expects the head as 'user:branch'
+	if f.Head != "" && !strings.Contains(f.Head, ":") {
+		opts.Head = owner + ":" + f.Head
+	}
+	return opts
+}
+
+// matches reports whether a pull request passes the filters
+func (f prFilter) matches(pr *github.PullRequest) bool {
+	switch f.State {
+	case "open", "closed":
+		if pr.GetState() != f.State {
+			return false
+		}
+	case "merged":
+		if pr.MergedAt == nil {
+			return false
+		}
+	}
+
+	if f.Base != "" && pr.GetBase().GetRef() != f.Base {
+		return false
+	}
+	if f.Head != "" && pr.GetHead().GetRef() != f.Head && pr.GetHead().GetLabel() != f.Head {
+		return false
+	}
+	if f.Author != "" && !strings.EqualFold(pr.GetUser().GetLogin(), f.Author) {
+		return false
+	}
+	if f.Label != "" && !containsFold(labelNames(pr.Labels), f.Label) {
+		return false
+	}
+	if f.Draft != nil && pr.GetDraft() != *f.Draft {
+		return false
+	}
+	return inRange(pr.GetCreatedAt().Time, f.CreatedSince, f.CreatedUntil) &&
+		inRange(pr.GetUpdatedAt().Time, f.UpdatedSince, f.UpdatedUntil)
+}
+
+// apply keeps the pull requests passing the filters
+func (f prFilter) apply(prs []*github.PullRequest) []*github.PullRequest {
+	filtered := make([]*github.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if f.matches(pr) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// parseQueryTime reads an optional date ('2006-01-02') or RFC 3339 timestamp query parameter
+func parseQueryTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid %s parameter, expected a date or an RFC 3339 timestamp", name)
+}
+
+// inRange reports whether t falls between since and until, zero bounds are open
+func inRange(t, since, until time.Time) bool {
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	if !until.IsZero() && t.After(until) {
+		return false
+	}
+	return true
+}
+
+// labelNames returns the name of every label
+func labelNames(labels []*github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.GetName())
+	}
+	return names
+}
+
+// sortPullRequests orders pull requests the same way GitHub does for the filter's sort and direction
+// Used when merging the pull requests of several repositories
+func sortPullRequests(prs []*github.PullRequest, f prFilter) {
+	descending := f.Direction != "asc"
+
+	sort.SliceStable(prs, func(i, j int) bool {
+		if descending {
+			i, j = j, i
+		}
+
+		switch f.Sort {
+		case "updated":
+			return prs[i].GetUpdatedAt().Before(prs[j].GetUpdatedAt().Time)
+		case "popularity":
+			return prs[i].GetComments() < prs[j].GetComments()
+		case "long-running":
+			// Oldest first when descending, like on GitHub
+			return prs[j].GetCreatedAt().Before(prs[i].GetCreatedAt().Time)
+		default:
+			return prs[i].GetCreatedAt().Before(prs[j].GetCreatedAt().Time)
+		}
+	})
+}
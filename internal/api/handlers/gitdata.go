@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// Git file modes accepted by the Git data API
+const (
+	modeFile       = "100644"
+	modeExecutable = "100755"
+	modeSymlink    = "120000"
+)
+
+// gitFile is a file written through the Git data API, Delete removes it from the base tree instead
+type gitFile struct {
+	Path    string
+	Mode    string
+	Content []byte
+	Delete  bool
+}
+
+// createTree uploads every file as a blob and creates a tree holding them
+// An empty baseTree creates a tree with only the given files
+func (a *Application) createTree(ctx context.Context, owner, repo, baseTree string, files []gitFile) (*github.Tree, error) {
+	entries := make([]*github.TreeEntry, 0, len(files))
+	for _, file := range files {
+		mode := file.Mode
+		if mode == "" {
+			mode = modeFile
+		}
+
+		// An entry without SHA nor content is sent with a null SHA, which deletes the path
+		if file.Delete {
+			entries = append(entries, &github.TreeEntry{Path: github.Ptr(file.Path), Mode: github.Ptr(mode), Type: github.Ptr("blob")})
+			continue
+		}
+
+		// Base64 keeps binary content intact
+		blob, _, err := a.githubClient.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+			Content:  github.Ptr(base64.StdEncoding.EncodeToString(file.Content)),
+			Encoding: github.Ptr("base64"),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &github.TreeEntry{
+			Path: github.Ptr(file.Path),
+			Mode: github.Ptr(mode),
+			Type: github.Ptr("blob"),
+			SHA:  blob.SHA,
+		})
+	}
+
+	tree, _, err := a.githubClient.Git.CreateTree(ctx, owner, repo, baseTree, entries)
+	return tree, err
+}
+
+// createCommit creates a commit of tree on top of parents, no parents creates a root commit
+func (a *Application) createCommit(ctx context.Context, owner, repo, message string, tree *github.Tree, parents []string, author *github.CommitAuthor) (*github.Commit, error) {
+	commit := &github.Commit{
+		Message: github.Ptr(message),
+		Tree:    tree,
+		Author:  author,
+	}
+	for _, parent := range parents {
+		commit.Parents = append(commit.Parents, &github.Commit{SHA: github.Ptr(parent)})
+	}
+
+	newCommit, _, err := a.githubClient.Git.CreateCommit(ctx, owner, repo, commit, nil)
+	return newCommit, err
+}
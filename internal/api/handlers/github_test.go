@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+)
+
+// fakeGitHub stands in for the GitHub API so tests drive the real handlers through go-github
+// Tests register the endpoints they expect, any other request fails the test
+type fakeGitHub struct {
+	t        *testing.T
+	mux      *http.ServeMux
+	mu       sync.Mutex
+	requests []string
+}
+
+// newFakeGitHub starts a fake GitHub and returns an Application talking to it as 'owner'
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *handlers.Application) {
+	return newFakeGitHubAt(t, "")
+}
+
+// newFakeGitHubAt is newFakeGitHub with the REST API served under path, such as '/api/v3' for GitHub Enterprise
+func newFakeGitHubAt(t *testing.T, path string) (*fakeGitHub, *handlers.Application) {
+	f := &fakeGitHub{t: t, mux: http.NewServeMux()}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	app, err := handlers.NewTestApplication(server.URL+path, "owner", t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+	return f, app
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+
+	if _, pattern := f.mux.Handler(r); pattern == "" {
+		f.t.Errorf("Unexpected GitHub request %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	// Served through the mux so handlers can read wildcards with r.PathValue
+	f.mux.ServeHTTP(w, r)
+}
+
+// handle registers a GitHub endpoint, such as 'GET /repos/owner/api/pulls/{number}'
+func (f *fakeGitHub) handle(pattern string, handler http.HandlerFunc) {
+	f.mux.HandleFunc(pattern, handler)
+}
+
+// reply registers a GitHub endpoint answering with a fixed status and JSON body
+func (f *fakeGitHub) reply(pattern string, status int, body any) {
+	f.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, status, body)
+	})
+}
+
+// replyPages registers a GitHub list endpoint serving one page per body, linked together like GitHub does
+func (f *fakeGitHub) replyPages(pattern string, pages ...any) {
+	f.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		if page > len(pages) {
+			writeJSON(w, http.StatusOK, []any{})
+			return
+		}
+
+		var links []string
+		addLink := func(target int, rel string) {
+			query := r.URL.Query()
+			query.Set("page", strconv.Itoa(target))
+			links = append(links, fmt.Sprintf("<http://%s%s?%s>; rel=\"%s\"", r.Host, r.URL.Path, query.Encode(), rel))
+		}
+		if page > 1 {
+			addLink(1, "first")
+			addLink(page-1, "prev")
+		}
+		if page < len(pages) {
+			addLink(page+1, "next")
+			addLink(len(pages), "last")
+		}
+		if len(links) > 0 {
+			w.Header().Set("Link", strings.Join(links, ", "))
+		}
+
+		writeJSON(w, http.StatusOK, pages[page-1])
+	})
+}
+
+// called reports whether GitHub received the request, such as 'PATCH /repos/owner/api'
+func (f *fakeGitHub) called(request string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Contains(f.requests, request)
+}
+
+// writeJSON answers a GitHub request with a JSON body
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// readJSON decodes the JSON body sent to GitHub
+func readJSON(t *testing.T, r *http.Request, v any) {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("Invalid JSON sent to GitHub: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github-api-service/internal/models"
+)
+
+// Some pull request changes, such as the draft state, are only exposed by the GraphQL API
+const (
+	markReadyForReviewMutation = `mutation($id: ID!) {
+        markPullRequestReadyForReview(input: {pullRequestId: $id}) { pullRequest { isDraft } }
+    }`
+	convertToDraftMutation = `mutation($id: ID!) {
+        convertPullRequestToDraft(input: {pullRequestId: $id}) { pullRequest { isDraft } }
+    }`
+)
+
+// graphQLRequest is the body of a GraphQL call
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// graphQLResponse holds the data or the errors of a GraphQL call
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQLError is returned when GitHub answers a GraphQL call with errors
+// GitHub reports those with a 200 so they never turn into a github.ErrorResponse
+type GraphQLError struct {
+	Type    string // NOT_FOUND, FORBIDDEN, UNPROCESSABLE...
+	Message string
+}
+
+func (e *GraphQLError) Error() string {
+	return e.Message
+}
+
+// status picks the status this service returns for the GraphQL error type
+func (e *GraphQLError) status() (int, string) {
+	switch e.Type {
+	case "NOT_FOUND":
+		return http.StatusNotFound, models.ErrCodeNotFound
+	case "FORBIDDEN":
+		return http.StatusForbidden, models.ErrCodeForbidden
+	default:
+		return http.StatusUnprocessableEntity, models.ErrCodeValidationFailed
+	}
+}
+
+// graphQLURL returns the GraphQL endpoint next to the REST API at baseURL
+// GitHub Enterprise serves REST under /api/v3/ and GraphQL under /api/graphql, github.com serves both at the root
+func graphQLURL(baseURL *url.URL) string {
+	if prefix, found := strings.CutSuffix(baseURL.Path, "/api/v3/"); found {
+		return baseURL.ResolveReference(&url.URL{Path: prefix + "/api/graphql"}).String()
+	}
+	return baseURL.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
+
+// graphQL runs a GraphQL query or mutation, decoding the data into result when it is not nil
+func (a *Application) graphQL(ctx context.Context, query string, variables map[string]any, result any) error {
+	req, err := a.githubClient.NewRequest(http.MethodPost, graphQLURL(a.githubClient.BaseURL), graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	var resp graphQLResponse
+	if _, err := a.githubClient.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return &GraphQLError{Type: resp.Errors[0].Type, Message: strings.Join(messages, "; ")}
+	}
+
+	if result == nil || resp.Data == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, result)
+}
+
+// setDraft marks a pull request ready for review, or converts it back to a draft
+func (a *Application) setDraft(ctx context.Context, nodeID string, draft bool) error {
+	mutation := markReadyForReviewMutation
+	if draft {
+		mutation = convertToDraftMutation
+	}
+	return a.graphQL(ctx, mutation, map[string]any{"id": nodeID}, nil)
+}
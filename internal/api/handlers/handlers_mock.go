@@ -14,59 +14,74 @@ import (
 // GitHubMock represents a mock implementation of a GitHub client
 // MockError allows us to mock an api failure
 type GitHubMock struct {
-	MockError     error
-	RepositoryList []*github.Repository  
-	PRList         []*github.PullRequest 
+	MockError      error
+	RepositoryList []*github.Repository
+	PRList         []*github.PullRequest
+}
+
+// notMocked answers the endpoints that are tested against a fake GitHub API through Application instead
+func (g *GitHubMock) notMocked(c *gin.Context) {
+	if g.MockError != nil {
+		respondWithGitHubError(c, g.MockError)
+		return
+	}
+
+	respondWithError(c, http.StatusNotImplemented, models.ErrCodeInternal, "Not implemented by the mock")
 }
 
 // Mock of CreateRepository handler function
 func (g *GitHubMock) CreateRepository(c *gin.Context) {
-    if g.MockError != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": g.MockError.Error()})
-        return
-    }
+	if g.MockError != nil {
+		respondWithGitHubError(c, g.MockError)
+		return
+	}
 
 	var repoRequest models.RepoRequest
 	if err := c.ShouldBindJSON(&repoRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBadRequest(c, err.Error())
 		return
 	}
 
-    newRepo := &github.Repository{
+	newRepo := &github.Repository{
 		Name:        github.Ptr(repoRequest.Name),
 		Description: github.Ptr(repoRequest.Description),
 		Private:     github.Ptr(repoRequest.Private),
 	}
 	g.RepositoryList = append(g.RepositoryList, newRepo)
 
-	response := models.RepoResponse{
-		Message:    "Successfully created repository",
-		Name:       newRepo.GetName(),
-		Description: newRepo.GetDescription(),
-		Private:    newRepo.GetPrivate(),
-	}
+	response := newRepoResponse("Successfully created repository", newRepo)
 
 	c.JSON(http.StatusCreated, response)
 }
 
+// Mock of CreateRepositoryFromTemplate handler function
+func (g *GitHubMock) CreateRepositoryFromTemplate(c *gin.Context) {
+	g.notMocked(c)
+}
+
 // Mock of ListRepositories handler function
 func (g *GitHubMock) ListRepositories(c *gin.Context) {
-    if g.MockError != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": g.MockError.Error()})
+	if g.MockError != nil {
+		respondWithGitHubError(c, g.MockError)
 		return
 	}
 
 	c.JSON(http.StatusOK, g.RepositoryList)
 }
 
+// Mock of GetRepository handler function
+func (g *GitHubMock) GetRepository(c *gin.Context) {
+	g.notMocked(c)
+}
+
 // Mock of DeleteRepository handler function
 func (g *GitHubMock) DeleteRepository(c *gin.Context) {
-    if g.MockError != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": g.MockError.Error()})
+	if g.MockError != nil {
+		respondWithGitHubError(c, g.MockError)
 		return
 	}
 
-    repoName := c.Param("repo")
+	repoName := c.Param("repo")
 	for i, repo := range g.RepositoryList {
 		if repo.GetName() == repoName {
 			g.RepositoryList = append(g.RepositoryList[:i], g.RepositoryList[i+1:]...)
@@ -80,10 +95,73 @@ func (g *GitHubMock) DeleteRepository(c *gin.Context) {
 	c.JSON(http.StatusNotFound, response)
 }
 
-// Mock of ListOpenPullRequests handler function
-func (g *GitHubMock) ListOpenPullRequests(c *gin.Context) {
-    if g.MockError != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": g.MockError.Error()})
+// Mock of ListBackups handler function
+func (g *GitHubMock) ListBackups(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of RestoreBackup handler function
+func (g *GitHubMock) RestoreBackup(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of CreatePullRequest handler function
+func (g *GitHubMock) CreatePullRequest(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of GetPullRequest handler function
+func (g *GitHubMock) GetPullRequest(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetPullRequestDiff handler function
+func (g *GitHubMock) GetPullRequestDiff(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetPullRequestPatch handler function
+func (g *GitHubMock) GetPullRequestPatch(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListAccountPullRequests handler function
+func (g *GitHubMock) ListAccountPullRequests(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetPullRequestMetrics handler function
+func (g *GitHubMock) GetPullRequestMetrics(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListStalePullRequests handler function
+func (g *GitHubMock) ListStalePullRequests(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListAccountStalePullRequests handler function
+func (g *GitHubMock) ListAccountStalePullRequests(c *gin.Context) { g.notMocked(c) }
+
+// Mock of MergePullRequest handler function
+func (g *GitHubMock) MergePullRequest(c *gin.Context) { g.notMocked(c) }
+
+// Mock of UpdatePullRequest handler function
+func (g *GitHubMock) UpdatePullRequest(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ClosePullRequests handler function
+func (g *GitHubMock) ClosePullRequests(c *gin.Context) { g.notMocked(c) }
+
+// Mock of CreateReview handler function
+func (g *GitHubMock) CreateReview(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListReviews handler function
+func (g *GitHubMock) ListReviews(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListReviewComments handler function
+func (g *GitHubMock) ListReviewComments(c *gin.Context) { g.notMocked(c) }
+
+// Mock of DismissReview handler function
+func (g *GitHubMock) DismissReview(c *gin.Context) { g.notMocked(c) }
+
+// Mock of RequestReviewers handler function
+func (g *GitHubMock) RequestReviewers(c *gin.Context) { g.notMocked(c) }
+
+// Mock of RemoveReviewers handler function
+func (g *GitHubMock) RemoveReviewers(c *gin.Context) { g.notMocked(c) }
+
+// Mock of ListPullRequests handler function
+func (g *GitHubMock) ListPullRequests(c *gin.Context) {
+	if g.MockError != nil {
+		respondWithGitHubError(c, g.MockError)
 		return
 	}
 
@@ -91,41 +169,93 @@ func (g *GitHubMock) ListOpenPullRequests(c *gin.Context) {
 	limitParam := c.DefaultQuery("limit", "0")
 	limit, err := strconv.Atoi(limitParam)
 	if err != nil || limit < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		respondWithBadRequest(c, "Invalid limit value")
 		return
 	}
 
 	// Check if repository exists
-	repoExists := false
-    for _, repo := range g.RepositoryList {
-        if repo.GetName() == repoName {
-            repoExists = true
-            break
-        }
-    }
-
-	if !repoExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Repository '%s' does not exist", repoName)})
+	if g.findRepository(repoName) == nil {
+		respondWithError(c, http.StatusNotFound, models.ErrCodeNotFound, fmt.Sprintf("Repository '%s' does not exist", repoName))
 		return
 	}
 
-	var formattedPRs []models.PullRequestResponse
-    for _, pr := range g.PRList {
-        if pr.GetBase().GetRepo().GetName() == repoName {
-            formattedPRs = append(formattedPRs, models.PullRequestResponse{
-                Title:     pr.GetTitle(),
-                Number:    pr.GetNumber(),
-                User:      pr.GetUser().GetLogin(),
-                CreatedAt: pr.GetCreatedAt().Time,
-                HtmlURL:   pr.GetHTMLURL(),
-            })
-        }
-    }
-
-	if limit > 0 && limit < len(formattedPRs) {
-		formattedPRs = formattedPRs[:limit]
+	repoPRs := make([]*github.PullRequest, 0, len(g.PRList))
+	for _, pr := range g.PRList {
+		if pr.GetBase().GetRepo().GetName() == repoName {
+			repoPRs = append(repoPRs, pr)
+		}
+	}
+
+	if limit > 0 && limit < len(repoPRs) {
+		repoPRs = repoPRs[:limit]
 	}
 
-	c.JSON(http.StatusOK, formattedPRs)
+	c.JSON(http.StatusOK, newPullRequestResponses(repoPRs))
 }
 
+// Mock of UpdateRepository handler function
+func (g *GitHubMock) UpdateRepository(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of ArchiveRepository handler function
+func (g *GitHubMock) ArchiveRepository(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of UnarchiveRepository handler function
+func (g *GitHubMock) UnarchiveRepository(c *gin.Context) {
+	g.notMocked(c)
+}
+
+// Mock of ListBranches handler function
+func (g *GitHubMock) ListBranches(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetBranch handler function
+func (g *GitHubMock) GetBranch(c *gin.Context) { g.notMocked(c) }
+
+// Mock of CreateBranch handler function
+func (g *GitHubMock) CreateBranch(c *gin.Context) { g.notMocked(c) }
+
+// Mock of RenameBranch handler function
+func (g *GitHubMock) RenameBranch(c *gin.Context) { g.notMocked(c) }
+
+// Mock of DeleteBranch handler function
+func (g *GitHubMock) DeleteBranch(c *gin.Context) { g.notMocked(c) }
+
+// Mock of PruneBranches handler function
+func (g *GitHubMock) PruneBranches(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetBranchProtection handler function
+func (g *GitHubMock) GetBranchProtection(c *gin.Context) { g.notMocked(c) }
+
+// Mock of UpdateBranchProtection handler function
+func (g *GitHubMock) UpdateBranchProtection(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetContents handler function
+func (g *GitHubMock) GetContents(c *gin.Context) { g.notMocked(c) }
+
+// Mock of UpdateFile handler function
+func (g *GitHubMock) UpdateFile(c *gin.Context) { g.notMocked(c) }
+
+// Mock of DeleteFile handler function
+func (g *GitHubMock) DeleteFile(c *gin.Context) { g.notMocked(c) }
+
+// Mock of CreateCommit handler function
+func (g *GitHubMock) CreateCommit(c *gin.Context) { g.notMocked(c) }
+
+// Mock of GetCompliance handler function
+func (g *GitHubMock) GetCompliance(c *gin.Context) { g.notMocked(c) }
+
+// Mock of RemediateCompliance handler function
+func (g *GitHubMock) RemediateCompliance(c *gin.Context) { g.notMocked(c) }
+
+// findRepository returns the mocked repository with the given name or nil
+func (g *GitHubMock) findRepository(name string) *github.Repository {
+	for _, repo := range g.RepositoryList {
+		if repo.GetName() == name {
+			return repo
+		}
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+const (
+	// GitHub computes mergeability in the background, so a fresh pull request may not have it yet
+	mergeabilityRetries    = 3
+	mergeabilityRetryDelay = time.Second
+)
+
+// Normalised state of a status check or check run
+const (
+	checkSuccess = "success"
+	checkPending = "pending"
+	checkFailure = "failure"
+)
+
+// mergeChecks holds what the pre-merge checks need besides the pull request itself
+type mergeChecks struct {
+	protection *github.Protection // nil when the base branch is not protected
+	checks     map[string]string  // State of every status check and check run on the head, by name
+	reviews    []*github.PullRequestReview
+}
+
+// MergePullRequest merges a pull request once the pre-merge checks pass
+// When something blocks the merge the response lists every blocker instead of GitHub's raw error
+func (a *Application) MergePullRequest(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var req models.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	pr, err := a.getPullRequestMergeability(ctx, repo, number)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	var checks mergeChecks
+	if pr.GetState() == "open" {
+		checks, err = a.fetchMergeChecks(ctx, repo, pr)
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+	}
+
+	if blockers := mergeBlockers(pr, req, checks); len(blockers) > 0 {
+		respondWithMergeBlockers(c, blockers)
+		return
+	}
+
+	method := mergeMethod(req)
+	result, _, err := a.githubClient.PullRequests.Merge(ctx, a.owner, repo, number, req.CommitMessage, &github.PullRequestOptions{
+		CommitTitle: req.CommitTitle,
+		SHA:         req.SHA,
+		MergeMethod: method,
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MergeResponse{
+		Message: "Pull request merged successfully",
+		SHA:     result.GetSHA(),
+		Method:  method,
+	})
+}
+
+// getPullRequestMergeability fetches the pull request, retrying while GitHub is still computing its mergeability
+func (a *Application) getPullRequestMergeability(ctx context.Context, repo string, number int) (*github.PullRequest, error) {
+	for attempt := 1; ; attempt++ {
+		pr, _, err := a.githubClient.PullRequests.Get(ctx, a.owner, repo, number)
+		if err != nil || pr.Mergeable != nil || pr.GetState() != "open" || attempt == mergeabilityRetries {
+			return pr, err
+		}
+		time.Sleep(mergeabilityRetryDelay)
+	}
+}
+
+// fetchMergeChecks gathers the base branch protection, the checks on the head commit and the reviews
+func (a *Application) fetchMergeChecks(ctx context.Context, repo string, pr *github.PullRequest) (mergeChecks, error) {
+	var checks mergeChecks
+
+	protection, _, err := a.githubClient.Repositories.GetBranchProtection(ctx, a.owner, repo, pr.GetBase().GetRef())
+	if err != nil && !isProtectionUnavailable(err) {
+		return mergeChecks{}, err
+	}
+	checks.protection = protection
+
+	checks.checks, err = a.headCheckStates(ctx, a.owner, repo, pr.GetHead().GetSHA())
+	if err != nil {
+		return mergeChecks{}, err
+	}
+
+	checks.reviews, _, err = fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+		return a.githubClient.PullRequests.ListReviews(ctx, a.owner, repo, pr.GetNumber(), &opts)
+	})
+	if err != nil {
+		return mergeChecks{}, err
+	}
+	return checks, nil
+}
+
+// headCheckStates merges commit statuses and check runs on ref into a single state per name
+func (a *Application) headCheckStates(ctx context.Context, owner, repo, ref string) (map[string]string, error) {
+	states := make(map[string]string)
+
+	statuses, _, err := fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.RepoStatus, *github.Response, error) {
+		combined, resp, err := a.githubClient.Repositories.GetCombinedStatus(ctx, owner, repo, ref, &opts)
+		if err != nil {
+			return nil, resp, err
+		}
+		return combined.Statuses, resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		states[status.GetContext()] = statusState(status.GetState())
+	}
+
+	runs, _, err := fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.CheckRun, *github.Response, error) {
+		results, resp, err := a.githubClient.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, &github.ListCheckRunsOptions{ListOptions: opts})
+		if err != nil {
+			return nil, resp, err
+		}
+		return results.CheckRuns, resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		states[run.GetName()] = checkRunState(run)
+	}
+
+	return states, nil
+}
+
+// isProtectionUnavailable reports whether the branch protection is missing or hidden from this token
+// The merge itself still enforces the protection, so the pre-checks simply skip it
+func isProtectionUnavailable(err error) bool {
+	if errors.Is(err, github.ErrBranchNotProtected) {
+		return true
+	}
+
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil &&
+		(errResp.Response.StatusCode == http.StatusForbidden || errResp.Response.StatusCode == http.StatusNotFound)
+}
+
+// mergeBlockers lists everything preventing the pull request from being merged
+func mergeBlockers(pr *github.PullRequest, req models.MergeRequest, checks mergeChecks) []models.MergeBlocker {
+	if pr.GetMerged() || pr.MergedAt != nil {
+		return []models.MergeBlocker{{Reason: models.MergeBlockerAlreadyMerged, Message: "Pull request is already merged"}}
+	}
+	if pr.GetState() != "open" {
+		return []models.MergeBlocker{{Reason: models.MergeBlockerClosed, Message: "Pull request is closed"}}
+	}
+
+	var blockers []models.MergeBlocker
+	if pr.GetDraft() {
+		blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerDraft, Message: "Pull request is still a draft"})
+	}
+	if req.SHA != "" && req.SHA != pr.GetHead().GetSHA() {
+		blockers = append(blockers, models.MergeBlocker{
+			Reason:  models.MergeBlockerHeadChanged,
+			Message: fmt.Sprintf("Head is at %s, not the expected %s", pr.GetHead().GetSHA(), req.SHA),
+		})
+	}
+
+	switch {
+	case pr.Mergeable == nil:
+		blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerMergeabilityUnknown, Message: "GitHub is still computing mergeability, retry shortly"})
+	case !pr.GetMergeable():
+		blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerConflicts, Message: "Head has conflicts with the base branch"})
+	}
+
+	if checks.protection != nil {
+		blockers = append(blockers, statusCheckBlockers(pr, checks)...)
+		blockers = append(blockers, reviewBlockers(checks)...)
+	}
+	return blockers
+}
+
+// statusCheckBlockers reports required status checks that did not succeed
+func statusCheckBlockers(pr *github.PullRequest, checks mergeChecks) []models.MergeBlocker {
+	required := checks.protection.GetRequiredStatusChecks()
+	if required == nil {
+		return nil
+	}
+
+	var blockers []models.MergeBlocker
+	if required.Strict && pr.GetMergeableState() == "behind" {
+		blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerBehind, Message: "Head is behind the base branch and must be updated"})
+	}
+
+	var contexts []string
+	if required.Contexts != nil {
+		contexts = append(contexts, *required.Contexts...)
+	}
+	if required.Checks != nil {
+		for _, check := range *required.Checks {
+			contexts = append(contexts, check.Context)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range contexts {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch state := checks.checks[name]; state {
+		case checkSuccess:
+		case "":
+			blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerStatusCheck, Message: "Required check has not run", Context: name})
+		default:
+			blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerStatusCheck, Message: fmt.Sprintf("Required check is %s", state), Context: name})
+		}
+	}
+	return blockers
+}
+
+// reviewBlockers reports outstanding change requests and missing approvals
+func reviewBlockers(checks mergeChecks) []models.MergeBlocker {
+	rules := checks.protection.GetRequiredPullRequestReviews()
+	if rules == nil {
+		return nil
+	}
+
+	var blockers []models.MergeBlocker
+	approvals := 0
+	for _, reviewer := range latestReviewStates(checks.reviews) {
+		switch reviewer.State {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			blockers = append(blockers, models.MergeBlocker{Reason: models.MergeBlockerChangesRequested, Message: "Reviewer requested changes", Context: reviewer.Reviewer})
+		}
+	}
+
+	if approvals < rules.RequiredApprovingReviewCount {
+		blockers = append(blockers, models.MergeBlocker{
+			Reason:  models.MergeBlockerApprovals,
+			Message: fmt.Sprintf("%d of %d required approvals", approvals, rules.RequiredApprovingReviewCount),
+		})
+	}
+	return blockers
+}
+
+// latestReviewStates returns the latest review state of each reviewer, in order of first review
+// Only approving, blocking and dismissed reviews override earlier ones, like on GitHub
+func latestReviewStates(reviews []*github.PullRequestReview) []models.ReviewerState {
+	var reviewers []string
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		login := review.GetUser().GetLogin()
+		current, seen := latest[login]
+		if !seen {
+			reviewers = append(reviewers, login)
+		}
+
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[login] = state
+		case "COMMENTED":
+			if current == "" {
+				latest[login] = state
+			}
+		}
+	}
+
+	states := make([]models.ReviewerState, 0, len(reviewers))
+	for _, login := range reviewers {
+		if latest[login] != "" {
+			states = append(states, models.ReviewerState{Reviewer: login, State: latest[login]})
+		}
+	}
+	return states
+}
+
+// respondWithMergeBlockers returns 409 with every reason the merge is blocked
+func respondWithMergeBlockers(c *gin.Context, blockers []models.MergeBlocker) {
+	c.JSON(http.StatusConflict, models.ErrorResponse{
+		Error:    "Pull request cannot be merged",
+		Code:     models.ErrCodeMergeBlocked,
+		Blockers: blockers,
+	})
+}
+
+// mergeMethod returns the requested merge method, merge when none was given
+func mergeMethod(req models.MergeRequest) string {
+	if req.Method == "" {
+		return "merge"
+	}
+	return req.Method
+}
+
+// statusState normalises a commit status state
+func statusState(state string) string {
+	switch state {
+	case "success":
+		return checkSuccess
+	case "pending":
+		return checkPending
+	default:
+		return checkFailure
+	}
+}
+
+// checkRunState normalises a check run, neutral and skipped runs count as successful like on GitHub
+func checkRunState(run *github.CheckRun) string {
+	if run.GetStatus() != "completed" {
+		return checkPending
+	}
+
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return checkSuccess
+	default:
+		return checkFailure
+	}
+}
+
+// parsePRNumber reads the ':number' URL parameter
+func parsePRNumber(c *gin.Context) (int, error) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		return 0, errors.New("Invalid pull request number")
+	}
+	return number, nil
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+const (
+	// Window covered by the metrics when no 'since' is given
+	defaultMetricsWindow = 90 * 24 * time.Hour
+	// How long computed metrics are served from memory
+	defaultMetricsCacheTTL = 10 * time.Minute
+)
+
+// Pull request size buckets by lines changed, the last one has no upper bound
+var sizeBuckets = []models.SizeBucket{
+	{Label: "XS", Min: 0, Max: 10},
+	{Label: "S", Min: 10, Max: 100},
+	{Label: "M", Min: 100, Max: 500},
+	{Label: "L", Min: 500, Max: 1000},
+	{Label: "XL", Min: 1000},
+}
+
+// GetPullRequestMetrics computes lead time, review latency, size and throughput metrics
+// for the pull requests opened, merged or closed since a date. Results are cached, '?refresh=true' recomputes them
+func (a *Application) GetPullRequestMetrics(c *gin.Context) {
+	repo := c.Param("repo")
+	now := time.Now()
+
+	since, refresh, err := parseMetricsQuery(c, now)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	key := metricsCacheKey(repo, since)
+	if !refresh {
+		if metrics, ok := a.metricsCache.get(key, now); ok {
+			c.Header("X-Cache", "HIT")
+			setTruncatedHeader(c, metrics.Truncated)
+			c.JSON(http.StatusOK, metrics)
+			return
+		}
+	}
+
+	ctx := context.Background()
+	prs, reviews, truncated, err := a.fetchMetricsData(ctx, repo, since)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	metrics := computePRMetrics(repo, since, now, prs, reviews)
+	metrics.Truncated = truncated
+	a.metricsCache.set(key, metrics, now)
+
+	c.Header("X-Cache", "MISS")
+	setTruncatedHeader(c, truncated)
+	c.JSON(http.StatusOK, metrics)
+}
+
+// fetchMetricsData fetches the pull requests opened, merged or closed since the date, with the sizes and reviews
+// of those opened since the date. The list endpoint has no sizes, so only those are fetched again along with
+// their reviews, merges and closes only need the list
+// Only the maxResults most recently updated pull requests are fetched, truncated reports whether others were left out
+func (a *Application) fetchMetricsData(ctx context.Context, repo string, since time.Time) ([]*github.PullRequest, map[int][]*github.PullRequestReview, bool, error) {
+	listed, truncated, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		opts := &github.PullRequestListOptions{State: "all", Sort: "updated", Direction: "desc", ListOptions: listOpts}
+		prs, resp, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, opts)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		// Opening, merging and closing all update a pull request, and the most recently updated come first,
+		// so the walk can stop at the first page reaching past the date
+		var active []*github.PullRequest
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(since) {
+				done := *resp
+				done.NextPage = 0
+				resp = &done
+				break
+			}
+			if activeSince(pr, since) {
+				active = append(active, pr)
+			}
+		}
+		return active, resp, nil
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var opened []*github.PullRequest
+	for _, pr := range listed {
+		if !pr.GetCreatedAt().Before(since) {
+			opened = append(opened, pr)
+		}
+	}
+
+	type prData struct {
+		pr      *github.PullRequest
+		reviews []*github.PullRequestReview
+	}
+	results, errs := fanOut(opened, fanOutConcurrency, func(pr *github.PullRequest) (prData, error) {
+		full, _, err := a.githubClient.PullRequests.Get(ctx, a.owner, repo, pr.GetNumber())
+		if err != nil {
+			return prData{}, err
+		}
+
+		// Reviews come oldest first, the first page holds the first review
+		reviews, _, err := a.githubClient.PullRequests.ListReviews(ctx, a.owner, repo, pr.GetNumber(), &github.ListOptions{PerPage: maxPerPage})
+		if err != nil {
+			return prData{}, err
+		}
+		return prData{pr: full, reviews: reviews}, nil
+	})
+
+	full := make(map[int]*github.PullRequest, len(opened))
+	reviews := make(map[int][]*github.PullRequestReview, len(opened))
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, nil, false, errs[i]
+		}
+		full[result.pr.GetNumber()] = result.pr
+		reviews[result.pr.GetNumber()] = result.reviews
+	}
+
+	prs := make([]*github.PullRequest, 0, len(listed))
+	for _, pr := range listed {
+		if fetched, ok := full[pr.GetNumber()]; ok {
+			pr = fetched
+		}
+		prs = append(prs, pr)
+	}
+	return prs, reviews, truncated, nil
+}
+
+// parseMetricsQuery reads the 'since' and 'refresh' query parameters
+// The window starts at midnight UTC so every request over the same days shares a cache entry
+func parseMetricsQuery(c *gin.Context, now time.Time) (time.Time, bool, error) {
+	since, err := parseQueryTime(c, "since")
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if since.IsZero() {
+		since = now.Add(-defaultMetricsWindow)
+	}
+	if since.After(now) {
+		return time.Time{}, false, errors.New("Invalid since parameter, must be in the past")
+	}
+	since = since.UTC().Truncate(24 * time.Hour)
+
+	refresh, err := parseOptionalBool(c, "refresh")
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return since, refresh != nil && *refresh, nil
+}
+
+// metricsCacheKey identifies the metrics of a repository over a window
+func metricsCacheKey(repo string, since time.Time) string {
+	return repo + "@" + strconv.FormatInt(since.Unix(), 10)
+}
+
+// metricsCacheTTLFromEnv reads how long metrics are cached from the METRICS_CACHE_TTL environment variable
+func metricsCacheTTLFromEnv(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultMetricsCacheTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid METRICS_CACHE_TTL value: %q", value)
+	}
+	return ttl, nil
+}
+
+// activeSince reports whether the pull request was opened, merged or closed at or after since
+func activeSince(pr *github.PullRequest, since time.Time) bool {
+	return !pr.GetCreatedAt().Before(since) || !pr.GetMergedAt().Before(since) || !pr.GetClosedAt().Before(since)
+}
+
+// computePRMetrics computes the metrics of the pull requests opened, merged or closed since the date
+// Sizes, reviews and open pull requests cover those opened since the date, merges and closes those that happened
+// since the date whenever the pull request was opened. reviews holds the reviews of each pull request by number
+func computePRMetrics(repo string, since, now time.Time, prs []*github.PullRequest, reviews map[int][]*github.PullRequestReview) models.PullRequestMetrics {
+	metrics := models.PullRequestMetrics{
+		Repository:          repo,
+		Since:               since,
+		GeneratedAt:         now,
+		ThroughputPerWeek:   []models.WeeklyThroughput{},
+		ThroughputPerAuthor: []models.AuthorThroughput{},
+	}
+
+	var firstReview, toMerge []float64
+	var sizes []int
+	weeks := make(map[string]*models.WeeklyThroughput)
+	authors := make(map[string]*models.AuthorThroughput)
+	authorMerge := make(map[string][]float64)
+
+	week := func(t time.Time) *models.WeeklyThroughput {
+		start := weekStart(t).Format(time.DateOnly)
+		if weeks[start] == nil {
+			weeks[start] = &models.WeeklyThroughput{Week: start}
+		}
+		return weeks[start]
+	}
+
+	author := func(login string) *models.AuthorThroughput {
+		if authors[login] == nil {
+			authors[login] = &models.AuthorThroughput{Author: login}
+		}
+		return authors[login]
+	}
+
+	for _, pr := range prs {
+		created := pr.GetCreatedAt().Time
+		login := pr.GetUser().GetLogin()
+
+		if !created.Before(since) {
+			metrics.Opened++
+			author(login).Opened++
+			week(created).Opened++
+			sizes = append(sizes, pr.GetAdditions()+pr.GetDeletions())
+			if pr.GetState() == "open" {
+				metrics.Open++
+			}
+			if reviewed, ok := firstReviewAt(pr, reviews[pr.GetNumber()]); ok {
+				firstReview = append(firstReview, reviewed.Sub(created).Hours())
+			}
+		}
+
+		switch {
+		case pr.MergedAt != nil && !pr.MergedAt.Before(since):
+			hours := pr.MergedAt.Sub(created).Hours()
+			metrics.Merged++
+			author(login).Merged++
+			week(pr.MergedAt.Time).Merged++
+			toMerge = append(toMerge, hours)
+			authorMerge[login] = append(authorMerge[login], hours)
+		case pr.MergedAt == nil && pr.GetState() == "closed" && !pr.GetClosedAt().Before(since):
+			metrics.ClosedUnmerged++
+		}
+	}
+
+	if closed := metrics.Merged + metrics.ClosedUnmerged; closed > 0 {
+		metrics.MergeRate = roundTo(float64(metrics.Merged)/float64(closed), 3)
+	}
+	metrics.TimeToFirstReview = newDurationStats(firstReview)
+	metrics.TimeToMerge = newDurationStats(toMerge)
+	metrics.Size = newSizeDistribution(sizes)
+
+	for _, w := range weeks {
+		metrics.ThroughputPerWeek = append(metrics.ThroughputPerWeek, *w)
+	}
+	sort.Slice(metrics.ThroughputPerWeek, func(i, j int) bool {
+		return metrics.ThroughputPerWeek[i].Week < metrics.ThroughputPerWeek[j].Week
+	})
+
+	for login, author := range authors {
+		author.MedianMergeHours = roundTo(percentile(authorMerge[login], 50), 2)
+		metrics.ThroughputPerAuthor = append(metrics.ThroughputPerAuthor, *author)
+	}
+	sort.Slice(metrics.ThroughputPerAuthor, func(i, j int) bool {
+		return metrics.ThroughputPerAuthor[i].Author < metrics.ThroughputPerAuthor[j].Author
+	})
+
+	return metrics
+}
+
+// firstReviewAt returns when someone other than the author first submitted a review
+func firstReviewAt(pr *github.PullRequest, reviews []*github.PullRequestReview) (time.Time, bool) {
+	var first time.Time
+	for _, review := range reviews {
+		if review.GetState() == "PENDING" || review.GetUser().GetLogin() == pr.GetUser().GetLogin() {
+			continue
+		}
+		if submitted := review.GetSubmittedAt().Time; first.IsZero() || submitted.Before(first) {
+			first = submitted
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// newDurationStats summarises durations given in hours
+func newDurationStats(hours []float64) models.DurationStats {
+	stats := models.DurationStats{Count: len(hours)}
+	if len(hours) == 0 {
+		return stats
+	}
+
+	var total float64
+	for _, h := range hours {
+		total += h
+	}
+	stats.AverageHours = roundTo(total/float64(len(hours)), 2)
+	stats.MedianHours = roundTo(percentile(hours, 50), 2)
+	stats.P90Hours = roundTo(percentile(hours, 90), 2)
+	return stats
+}
+
+// newSizeDistribution counts the sizes in each bucket
+func newSizeDistribution(sizes []int) models.SizeDistribution {
+	distribution := models.SizeDistribution{Buckets: make([]models.SizeBucket, len(sizeBuckets))}
+	copy(distribution.Buckets, sizeBuckets)
+
+	lines := make([]float64, 0, len(sizes))
+	for _, size := range sizes {
+		lines = append(lines, float64(size))
+		for i, bucket := range distribution.Buckets {
+			if size >= bucket.Min && (bucket.Max == 0 || size < bucket.Max) {
+				distribution.Buckets[i].Count++
+				break
+			}
+		}
+	}
+	distribution.MedianLines = int(math.Round(percentile(lines, 50)))
+	return distribution
+}
+
+// percentile returns the p-th percentile of values using linear interpolation, 0 when empty
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// weekStart returns the Monday starting the week of t, in UTC
+func weekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// roundTo rounds value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,232 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequestMetrics(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC) }
+	review := func(login string, submitted time.Time) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.Ptr(login)}, State: github.Ptr("COMMENTED"), SubmittedAt: &github.Timestamp{Time: submitted}}
+	}
+
+	newPRs := func() []*github.PullRequest {
+		first := testPullRequest(1, "closed", "alice", "first", at(6, 10))
+		first.MergedAt = &github.Timestamp{Time: at(7, 10)}
+		first.ClosedAt, first.UpdatedAt = first.MergedAt, first.MergedAt
+		first.Additions, first.Deletions = github.Ptr(5), github.Ptr(3)
+
+		second := testPullRequest(2, "closed", "bob", "second", at(8, 0))
+		second.MergedAt = &github.Timestamp{Time: at(14, 0)}
+		second.ClosedAt, second.UpdatedAt = second.MergedAt, second.MergedAt
+		second.Additions = github.Ptr(150)
+
+		abandoned := testPullRequest(3, "closed", "alice", "abandoned", at(13, 0))
+		abandoned.ClosedAt = &github.Timestamp{Time: at(13, 5)}
+		abandoned.UpdatedAt = abandoned.ClosedAt
+		abandoned.Additions = github.Ptr(40)
+
+		open := testPullRequest(4, "open", "alice", "open", at(14, 0))
+		open.Additions = github.Ptr(2000)
+
+		old := testPullRequest(5, "open", "alice", "old", at(1, 0))
+
+		return []*github.PullRequest{first, second, abandoned, open, old}
+	}
+
+	// newFakeClient serves the pull requests of 'test-repo', the most recently updated first like GitHub
+	// prs is read on every request, so pull requests added by the test show up on the next load
+	newFakeClient := func(t *testing.T, prs *[]*github.PullRequest) *handlers.Application {
+		reviews := map[string][]*github.PullRequestReview{
+			"1": {review("alice", at(6, 11)), review("bob", at(6, 12))},
+			"2": {review("carol", at(9, 0))},
+		}
+
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /repos/owner/test-repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+			listed := slices.Clone(*prs)
+			slices.SortStableFunc(listed, func(a, b *github.PullRequest) int {
+				return b.GetUpdatedAt().Compare(a.GetUpdatedAt().Time)
+			})
+			writeJSON(w, http.StatusOK, listed)
+		})
+		fake.handle("GET /repos/owner/test-repo/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+			for _, pr := range *prs {
+				if strconv.Itoa(pr.GetNumber()) == r.PathValue("number") {
+					writeJSON(w, http.StatusOK, pr)
+					return
+				}
+			}
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		})
+		fake.handle("GET /repos/owner/test-repo/pulls/{number}/reviews", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, append([]*github.PullRequestReview{}, reviews[r.PathValue("number")]...))
+		})
+		return app
+	}
+
+	getMetrics := func(t *testing.T, app *handlers.Application, query string) (string, models.PullRequestMetrics) {
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/metrics/pull-requests?"+query, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var metrics models.PullRequestMetrics
+		err := json.Unmarshal(w.Body.Bytes(), &metrics)
+		assert.NoError(t, err, errJSONUnmarshal)
+		return w.Header().Get("X-Cache"), metrics
+	}
+
+	t.Run("Compute metrics", func(t *testing.T) {
+		prs := newPRs()
+		_, metrics := getMetrics(t, newFakeClient(t, &prs), "since=2025-01-06")
+
+		assert.Equal(t, 4, metrics.Opened, "PRs opened before since should be ignored")
+		assert.Equal(t, 2, metrics.Merged, "2 PRs should be merged")
+		assert.Equal(t, 1, metrics.ClosedUnmerged, "1 PR should be closed without merging")
+		assert.Equal(t, 1, metrics.Open, "1 PR should be open")
+		assert.Equal(t, 0.667, metrics.MergeRate, "Merge rate should be 2 out of 3")
+
+		assert.Equal(t, models.DurationStats{Count: 2, AverageHours: 84, MedianHours: 84, P90Hours: 132}, metrics.TimeToMerge, "Time to merge should match")
+		assert.Equal(t, models.DurationStats{Count: 2, AverageHours: 13, MedianHours: 13, P90Hours: 21.8}, metrics.TimeToFirstReview, "Reviews by the author should be ignored")
+
+		assert.Equal(t, 95, metrics.Size.MedianLines, "Median size should match")
+		counts := map[string]int{}
+		for _, bucket := range metrics.Size.Buckets {
+			counts[bucket.Label] = bucket.Count
+		}
+		assert.Equal(t, map[string]int{"XS": 1, "S": 1, "M": 1, "L": 0, "XL": 1}, counts, "Size distribution should match")
+
+		assert.Equal(t, []models.WeeklyThroughput{
+			{Week: "2025-01-06", Opened: 2, Merged: 1},
+			{Week: "2025-01-13", Opened: 2, Merged: 1},
+		}, metrics.ThroughputPerWeek, "Weekly throughput should match")
+		assert.Equal(t, []models.AuthorThroughput{
+			{Author: "alice", Opened: 3, Merged: 1, MedianMergeHours: 24},
+			{Author: "bob", Opened: 1, Merged: 1, MedianMergeHours: 144},
+		}, metrics.ThroughputPerAuthor, "Author throughput should match")
+	})
+
+	t.Run("Metrics are cached", func(t *testing.T) {
+		prs := newPRs()
+		app := newFakeClient(t, &prs)
+
+		cache, metrics := getMetrics(t, app, "since=2025-01-06")
+		assert.Equal(t, "MISS", cache, "First load should compute the metrics")
+		assert.Equal(t, 4, metrics.Opened, "4 PRs should be opened")
+
+		prs = append(prs, testPullRequest(6, "open", "bob", "new", at(15, 0)))
+
+		cache, metrics = getMetrics(t, app, "since=2025-01-06")
+		assert.Equal(t, "HIT", cache, "Second load should be served from the cache")
+		assert.Equal(t, 4, metrics.Opened, "Cached metrics should not change")
+
+		cache, metrics = getMetrics(t, app, "since=2025-01-06&refresh=true")
+		assert.Equal(t, "MISS", cache, "Refresh should recompute the metrics")
+		assert.Equal(t, 5, metrics.Opened, "Recomputed metrics should include the new PR")
+	})
+
+	t.Run("Windows starting on the same day share a cache entry", func(t *testing.T) {
+		prs := newPRs()
+		app := newFakeClient(t, &prs)
+
+		cache, metrics := getMetrics(t, app, "since=2025-01-06T10:00:00Z")
+		assert.Equal(t, "MISS", cache, "First load should compute the metrics")
+		assert.Equal(t, time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), metrics.Since, "Window should start at midnight")
+
+		cache, _ = getMetrics(t, app, "since=2025-01-06T18:30:00Z")
+		assert.Equal(t, "HIT", cache, "Another time of the same day should be served from the cache")
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"since=last-week", "since=2999-01-01", "refresh=maybe"} {
+			// Nothing is registered, invalid parameters must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "GET", "/repositories/test-repo/metrics/pull-requests?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for "+query)
+		}
+	})
+
+	t.Run("Report metrics over a truncated list", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		app.SetMaxResults(2)
+
+		created := time.Now().Add(-time.Hour)
+		prs := make([]*github.PullRequest, 0, 3)
+		for number := 3; number > 0; number-- {
+			prs = append(prs, testPullRequest(number, "open", "alice", "feature", created))
+		}
+		fake.reply("GET /repos/owner/api/pulls", http.StatusOK, prs)
+		fake.handle("GET /repos/owner/api/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+			number, _ := strconv.Atoi(r.PathValue("number"))
+			writeJSON(w, http.StatusOK, prs[len(prs)-number])
+		})
+		fake.reply("GET /repos/owner/api/pulls/{number}/reviews", http.StatusOK, []*github.PullRequestReview{})
+
+		w := serveJSON(t, app, "GET", "/repositories/api/metrics/pull-requests", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "true", w.Header().Get("X-Truncated"), "Truncation should be flagged in the headers")
+
+		var metrics models.PullRequestMetrics
+		err := json.Unmarshal(w.Body.Bytes(), &metrics)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.True(t, metrics.Truncated, "Truncation should be flagged in the metrics")
+		assert.Equal(t, 2, metrics.Opened, "Only the newest PRs should be counted")
+		assert.False(t, fake.called("GET /repos/owner/api/pulls/1"), "PRs past the cap should not be fetched")
+
+		w = serveJSON(t, app, "GET", "/repositories/api/metrics/pull-requests", "")
+		assert.Equal(t, "HIT", w.Header().Get("X-Cache"), "Second load should be served from the cache")
+		assert.Equal(t, "true", w.Header().Get("X-Truncated"), "Cached metrics should still be flagged")
+	})
+
+	t.Run("Count merges and closes of pull requests opened earlier", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		since := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+		opened := testPullRequest(4, "open", "alice", "opened", since.Add(48*time.Hour))
+		merged := testPullRequest(3, "closed", "bob", "merged", since.Add(-72*time.Hour))
+		merged.MergedAt = &github.Timestamp{Time: since.Add(24 * time.Hour)}
+		merged.ClosedAt, merged.UpdatedAt = merged.MergedAt, merged.MergedAt
+		closed := testPullRequest(2, "closed", "bob", "closed", since.Add(-96*time.Hour))
+		closed.ClosedAt = &github.Timestamp{Time: since.Add(12 * time.Hour)}
+		closed.UpdatedAt = closed.ClosedAt
+		old := testPullRequest(1, "closed", "alice", "old", since.Add(-120*time.Hour))
+		old.MergedAt = &github.Timestamp{Time: since.Add(-time.Hour)}
+		old.ClosedAt, old.UpdatedAt = old.MergedAt, old.MergedAt
+
+		fake.handle("GET /repos/owner/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "updated", r.URL.Query().Get("sort"), "PRs should be listed by last update")
+			writeJSON(w, http.StatusOK, []*github.PullRequest{opened, merged, closed, old})
+		})
+		fake.reply("GET /repos/owner/api/pulls/4", http.StatusOK, opened)
+		fake.reply("GET /repos/owner/api/pulls/4/reviews", http.StatusOK, []*github.PullRequestReview{})
+
+		w := serveJSON(t, app, "GET", "/repositories/api/metrics/pull-requests?since=2025-01-06", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var metrics models.PullRequestMetrics
+		err := json.Unmarshal(w.Body.Bytes(), &metrics)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 1, metrics.Opened, "Only PRs opened since the date should count as opened")
+		assert.Equal(t, 1, metrics.Merged, "A merge in the window should count whenever the PR was opened")
+		assert.Equal(t, 1, metrics.ClosedUnmerged, "A close in the window should count whenever the PR was opened")
+		assert.Equal(t, models.DurationStats{Count: 1, AverageHours: 96, MedianHours: 96, P90Hours: 96}, metrics.TimeToMerge, "Time to merge should span from opening")
+		assert.Equal(t, []models.WeeklyThroughput{{Week: "2025-01-06", Opened: 1, Merged: 1}}, metrics.ThroughputPerWeek, "Weekly throughput should match")
+		assert.False(t, fake.called("GET /repos/owner/api/pulls/3"), "PRs opened before the date should not be fetched again")
+		assert.False(t, fake.called("GET /repos/owner/api/pulls/3/reviews"), "Reviews of PRs opened before the date should not be fetched")
+	})
+}
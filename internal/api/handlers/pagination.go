@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+const (
+	// GitHub does not allow more than 100 items per page
+	maxPerPage = 100
+	// Hard cap on the number of items returned when walking every page
+	defaultMaxResults = 1000
+)
+
+// pagination holds the page requested by the client
+// A zero Page means the client wants every page up to the configured cap
+type pagination struct {
+	Page    int
+	PerPage int
+}
+
+// parsePagination reads the optional 'page' and 'per_page' query parameters
+func parsePagination(c *gin.Context) (pagination, error) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "0"))
+	if err != nil || page < 0 {
+		return pagination{}, errors.New("Invalid page parameter")
+	}
+
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(maxPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return pagination{}, fmt.Errorf("Invalid per_page parameter, must be between 1 and %d", maxPerPage)
+	}
+
+	return pagination{Page: page, PerPage: perPage}, nil
+}
+
+// fetchAllPages follows the NextPage links returned by GitHub until there are no pages left
+// or maxResults items have been collected. The boolean reports whether results were truncated
+func fetchAllPages[T any](perPage, maxResults int, fetch func(opts github.ListOptions) ([]T, *github.Response, error)) ([]T, bool, error) {
+	var all []T
+	opts := github.ListOptions{Page: 1, PerPage: perPage}
+
+	for {
+		items, resp, err := fetch(opts)
+		if err != nil {
+			return nil, false, err
+		}
+		all = append(all, items...)
+
+		if maxResults > 0 && len(all) >= maxResults {
+			truncated := len(all) > maxResults || resp.NextPage != 0
+			return all[:maxResults], truncated, nil
+		}
+		if resp.NextPage == 0 {
+			return all, false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// fetchPages fetches the page the client asked for, or every page up to maxResults when it asked for none,
+// and sets the matching pagination headers
+func fetchPages[T any](c *gin.Context, p pagination, maxResults int, fetch func(opts github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	return fetchPagesWithLimit(c, p, maxResults, 0, fetch)
+}
+
+// fetchPagesWithLimit is fetchPages for endpoints taking a 'limit', walking every page stops once limit items
+// are collected, which is not reported as truncated since the client asked for no more
+func fetchPagesWithLimit[T any](c *gin.Context, p pagination, maxResults, limit int, fetch func(opts github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	if p.Page == 0 && limit > 0 && limit < maxResults {
+		items, _, err := fetchAllPages(p.PerPage, limit, fetch)
+		return items, err
+	}
+
+	if p.Page > 0 {
+		items, resp, err := fetch(github.ListOptions{Page: p.Page, PerPage: p.PerPage})
+		if err != nil {
+			return nil, err
+		}
+		setPaginationHeaders(c, p.Page, resp.NextPage, resp.LastPage)
+		return items, nil
+	}
+
+	items, truncated, err := fetchAllPages(p.PerPage, maxResults, fetch)
+	if err != nil {
+		return nil, err
+	}
+	setTruncatedHeader(c, truncated)
+	return items, nil
+}
+
+// paginateSlice returns a single page of items along with the next and last page numbers
+// Used when the full result set is already in memory
+func paginateSlice[T any](items []T, p pagination) ([]T, int, int) {
+	lastPage := (len(items) + p.PerPage - 1) / p.PerPage
+	start := (p.Page - 1) * p.PerPage
+	if start >= len(items) {
+		return []T{}, 0, lastPage
+	}
+
+	end := start + p.PerPage
+	nextPage := p.Page + 1
+	if end >= len(items) {
+		end = len(items)
+		nextPage = 0
+	}
+
+	return items[start:end], nextPage, lastPage
+}
+
+// setPaginationHeaders exposes the surrounding pages through a Link header and X-Next-Page
+// so clients can walk pages themselves
+func setPaginationHeaders(c *gin.Context, page, nextPage, lastPage int) {
+	var links []string
+	addLink := func(target int, rel string) {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(target))
+		u := *c.Request.URL
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel))
+	}
+
+	if page > 1 {
+		addLink(1, "first")
+		addLink(page-1, "prev")
+	}
+	if nextPage != 0 {
+		addLink(nextPage, "next")
+		c.Header("X-Next-Page", strconv.Itoa(nextPage))
+	}
+	if lastPage != 0 {
+		addLink(lastPage, "last")
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// setTruncatedHeader tells clients that the configured cap cut the result set short
+func setTruncatedHeader(c *gin.Context, truncated bool) {
+	if truncated {
+		c.Header("X-Truncated", "true")
+	}
+}
+
+// maxResultsFromEnv reads the hard cap from the MAX_RESULTS environment variable
+func maxResultsFromEnv(value string) (int, error) {
+	if value == "" {
+		return defaultMaxResults, nil
+	}
+
+	maxResults, err := strconv.Atoi(value)
+	if err != nil || maxResults < 1 {
+		return 0, fmt.Errorf("invalid MAX_RESULTS value: %q", value)
+	}
+	return maxResults, nil
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/go-github/v68/github"
+)
+
+// GetBranchProtection returns the protection of a branch as a policy that can be sent back to UpdateBranchProtection
+func (a *Application) GetBranchProtection(c *gin.Context) {
+	repo, branch := c.Param("repo"), c.Param("branch")
+
+	format, err := parsePolicyFormat(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	protection, _, err := a.githubClient.Repositories.GetBranchProtection(ctx, a.owner, repo, branch)
+	if errors.Is(err, github.ErrBranchNotProtected) {
+		respondWithError(c, http.StatusNotFound, models.ErrCodeNotFound, notProtectedMessage(branch))
+		return
+	}
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	respondWithPolicyFormat(c, format, http.StatusOK, newProtectionPolicy(protection))
+}
+
+// UpdateBranchProtection applies a protection policy sent as JSON or YAML and reports what it changed
+// Nothing is sent to GitHub when the protection already matches, or when 'dry_run=true' is given
+func (a *Application) UpdateBranchProtection(c *gin.Context) {
+	repo, branch := c.Param("repo"), c.Param("branch")
+
+	format, err := parsePolicyFormat(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	dryRun, err := parseOptionalBool(c, "dry_run")
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var policy models.BranchProtectionPolicy
+	if err := c.ShouldBindWith(&policy, policyBinding(c)); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	policy = normalizePolicy(policy)
+
+	ctx := context.Background()
+	current, _, err := a.githubClient.Repositories.GetBranchProtection(ctx, a.owner, repo, branch)
+	if err != nil && !errors.Is(err, github.ErrBranchNotProtected) {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	response := newProtectionResponse(branch, current, policy, dryRun != nil && *dryRun)
+	if response.Applied {
+		updated, _, err := a.githubClient.Repositories.UpdateBranchProtection(ctx, a.owner, repo, branch, newProtectionRequest(policy, current))
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+		applied := newProtectionPolicy(updated)
+		response.Policy = &applied
+	}
+
+	respondWithPolicyFormat(c, format, http.StatusOK, response)
+}
+
+// newProtectionResponse diffs the current protection against the policy, current is nil when the branch is not protected
+// Applied is set when the policy must be sent to GitHub, Policy then still holds the current protection
+func newProtectionResponse(branch string, current *github.Protection, policy models.BranchProtectionPolicy, dryRun bool) models.BranchProtectionResponse {
+	response := models.BranchProtectionResponse{Branch: branch, DryRun: dryRun}
+
+	var currentPolicy *models.BranchProtectionPolicy
+	if current != nil {
+		p := newProtectionPolicy(current)
+		currentPolicy = &p
+	}
+
+	response.Changes = protectionChanges(currentPolicy, policy)
+	response.Policy = currentPolicy
+	response.Applied = len(response.Changes) > 0 && !dryRun
+	return response
+}
+
+// newProtectionPolicy converts a GitHub branch protection into a policy
+func newProtectionPolicy(protection *github.Protection) models.BranchProtectionPolicy {
+	var policy models.BranchProtectionPolicy
+	if linear := protection.GetRequireLinearHistory(); linear != nil {
+		policy.RequireLinearHistory = linear.Enabled
+	}
+	if forcePushes := protection.GetAllowForcePushes(); forcePushes != nil {
+		policy.AllowForcePushes = forcePushes.Enabled
+	}
+	if deletions := protection.GetAllowDeletions(); deletions != nil {
+		policy.AllowDeletions = deletions.Enabled
+	}
+	if admins := protection.GetEnforceAdmins(); admins != nil {
+		policy.EnforceAdmins = admins.Enabled
+	}
+
+	if reviews := protection.GetRequiredPullRequestReviews(); reviews != nil {
+		policy.RequiredReviews = &models.RequiredReviewsPolicy{
+			RequiredApprovingReviewCount: reviews.RequiredApprovingReviewCount,
+			RequireCodeOwnerReviews:      reviews.RequireCodeOwnerReviews,
+			DismissStaleReviews:          reviews.DismissStaleReviews,
+			RequireLastPushApproval:      reviews.RequireLastPushApproval,
+		}
+	}
+
+	if checks := protection.GetRequiredStatusChecks(); checks != nil {
+		policy.RequiredStatusChecks = &models.RequiredStatusChecksPolicy{Strict: checks.Strict}
+		if checks.Contexts != nil {
+			policy.RequiredStatusChecks.Contexts = append(policy.RequiredStatusChecks.Contexts, *checks.Contexts...)
+		}
+		// Checks bound to an app are listed there as well, the policy only keeps their name
+		if checks.Checks != nil {
+			for _, check := range *checks.Checks {
+				policy.RequiredStatusChecks.Contexts = append(policy.RequiredStatusChecks.Contexts, check.Context)
+			}
+		}
+	}
+
+	return normalizePolicy(policy)
+}
+
+// newProtectionRequest converts a policy into the protection sent to GitHub
+// GitHub replaces the whole protection, so every setting the policy does not cover is carried over from the
+// current protection: push and dismissal restrictions, pull request bypass allowances, the app bound to each
+// required check, conversation resolution, branch locking, creation blocking and fork syncing
+func newProtectionRequest(policy models.BranchProtectionPolicy, current *github.Protection) *github.ProtectionRequest {
+	req := &github.ProtectionRequest{
+		EnforceAdmins:        policy.EnforceAdmins,
+		RequireLinearHistory: github.Ptr(policy.RequireLinearHistory),
+		AllowForcePushes:     github.Ptr(policy.AllowForcePushes),
+		AllowDeletions:       github.Ptr(policy.AllowDeletions),
+	}
+
+	if reviews := policy.RequiredReviews; reviews != nil {
+		req.RequiredPullRequestReviews = &github.PullRequestReviewsEnforcementRequest{
+			RequiredApprovingReviewCount: reviews.RequiredApprovingReviewCount,
+			RequireCodeOwnerReviews:      reviews.RequireCodeOwnerReviews,
+			DismissStaleReviews:          reviews.DismissStaleReviews,
+			RequireLastPushApproval:      github.Ptr(reviews.RequireLastPushApproval),
+		}
+		if currentReviews := current.GetRequiredPullRequestReviews(); currentReviews != nil {
+			if dismissal := currentReviews.DismissalRestrictions; dismissal != nil {
+				users, teams, apps := actorNames(dismissal.Users, dismissal.Teams, dismissal.Apps)
+				req.RequiredPullRequestReviews.DismissalRestrictionsRequest = &github.DismissalRestrictionsRequest{Users: &users, Teams: &teams, Apps: &apps}
+			}
+			if bypass := currentReviews.BypassPullRequestAllowances; bypass != nil {
+				users, teams, apps := actorNames(bypass.Users, bypass.Teams, bypass.Apps)
+				req.RequiredPullRequestReviews.BypassPullRequestAllowancesRequest = &github.BypassPullRequestAllowancesRequest{Users: users, Teams: teams, Apps: apps}
+			}
+		}
+	}
+
+	if checks := policy.RequiredStatusChecks; checks != nil {
+		req.RequiredStatusChecks = &github.RequiredStatusChecks{Strict: checks.Strict, Checks: requiredChecks(checks.Contexts, current.GetRequiredStatusChecks())}
+	}
+
+	if restrictions := current.GetRestrictions(); restrictions != nil {
+		users, teams, apps := actorNames(restrictions.Users, restrictions.Teams, restrictions.Apps)
+		req.Restrictions = &github.BranchRestrictionsRequest{Users: users, Teams: teams, Apps: apps}
+	}
+	if resolution := current.GetRequiredConversationResolution(); resolution != nil {
+		req.RequiredConversationResolution = github.Ptr(resolution.Enabled)
+	}
+	if lock := current.GetLockBranch(); lock != nil {
+		req.LockBranch = lock.Enabled
+	}
+	if creations := current.GetBlockCreations(); creations != nil {
+		req.BlockCreations = creations.Enabled
+	}
+	if forkSyncing := current.GetAllowForkSyncing(); forkSyncing != nil {
+		req.AllowForkSyncing = forkSyncing.Enabled
+	}
+
+	return req
+}
+
+// requiredChecks lists the checks of the policy, keeping the app each one is bound to in the current protection
+// Checks new to the policy have no app, GitHub then binds them to the app that last reported them
+func requiredChecks(contexts []string, current *github.RequiredStatusChecks) *[]*github.RequiredStatusCheck {
+	appIDs := make(map[string]*int64)
+	if current != nil && current.Checks != nil {
+		for _, check := range *current.Checks {
+			appIDs[check.Context] = check.AppID
+		}
+	}
+
+	checks := make([]*github.RequiredStatusCheck, 0, len(contexts))
+	for _, context := range contexts {
+		checks = append(checks, &github.RequiredStatusCheck{Context: context, AppID: appIDs[context]})
+	}
+	return &checks
+}
+
+// actorNames converts the users, teams and apps of a restriction into the logins and slugs GitHub expects back
+func actorNames(users []*github.User, teams []*github.Team, apps []*github.App) ([]string, []string, []string) {
+	logins, teamSlugs, appSlugs := []string{}, []string{}, []string{}
+	for _, user := range users {
+		logins = append(logins, user.GetLogin())
+	}
+	for _, team := range teams {
+		teamSlugs = append(teamSlugs, team.GetSlug())
+	}
+	for _, app := range apps {
+		appSlugs = append(appSlugs, app.GetSlug())
+	}
+	return logins, teamSlugs, appSlugs
+}
+
+// normalizePolicy sorts and deduplicates the status check contexts so the order they are listed in does not matter
+func normalizePolicy(policy models.BranchProtectionPolicy) models.BranchProtectionPolicy {
+	if policy.RequiredStatusChecks == nil {
+		return policy
+	}
+
+	checks := *policy.RequiredStatusChecks
+	seen := make(map[string]bool)
+	contexts := []string{}
+	for _, context := range checks.Contexts {
+		if !seen[context] {
+			seen[context] = true
+			contexts = append(contexts, context)
+		}
+	}
+	sort.Strings(contexts)
+	checks.Contexts = contexts
+	policy.RequiredStatusChecks = &checks
+
+	return policy
+}
+
+// protectionChanges lists every field of the policy that differs from the current protection
+// A nil current policy means the branch is not protected yet
+func protectionChanges(current *models.BranchProtectionPolicy, requested models.BranchProtectionPolicy) []models.ProtectionChange {
+	changes := []models.ProtectionChange{}
+	diff := func(field string, from, to any) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, models.ProtectionChange{Field: field, Current: from, Requested: to})
+		}
+	}
+
+	if current == nil {
+		diff("protected", false, true)
+		current = &models.BranchProtectionPolicy{}
+	}
+
+	// Sections turned on or off are reported whole, otherwise field by field
+	if current.RequiredReviews == nil || requested.RequiredReviews == nil {
+		diff("required_reviews", current.RequiredReviews, requested.RequiredReviews)
+	} else {
+		from, to := current.RequiredReviews, requested.RequiredReviews
+		diff("required_reviews.required_approving_review_count", from.RequiredApprovingReviewCount, to.RequiredApprovingReviewCount)
+		diff("required_reviews.require_code_owner_reviews", from.RequireCodeOwnerReviews, to.RequireCodeOwnerReviews)
+		diff("required_reviews.dismiss_stale_reviews", from.DismissStaleReviews, to.DismissStaleReviews)
+		diff("required_reviews.require_last_push_approval", from.RequireLastPushApproval, to.RequireLastPushApproval)
+	}
+
+	if current.RequiredStatusChecks == nil || requested.RequiredStatusChecks == nil {
+		diff("required_status_checks", current.RequiredStatusChecks, requested.RequiredStatusChecks)
+	} else {
+		from, to := current.RequiredStatusChecks, requested.RequiredStatusChecks
+		diff("required_status_checks.strict", from.Strict, to.Strict)
+		diff("required_status_checks.contexts", from.Contexts, to.Contexts)
+	}
+
+	diff("require_linear_history", current.RequireLinearHistory, requested.RequireLinearHistory)
+	diff("allow_force_pushes", current.AllowForcePushes, requested.AllowForcePushes)
+	diff("allow_deletions", current.AllowDeletions, requested.AllowDeletions)
+	diff("enforce_admins", current.EnforceAdmins, requested.EnforceAdmins)
+
+	return changes
+}
+
+// parsePolicyFormat picks the response format from the 'format' query parameter, or YAML when the client accepts it
+func parsePolicyFormat(c *gin.Context) (string, error) {
+	format := c.Query("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.GetHeader("Accept"), "yaml") {
+			format = "yaml"
+		}
+	}
+	if err := oneOf("format", format, "json", "yaml"); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// policyBinding decodes YAML bodies by their Content-Type and anything else as JSON
+// so a body without a Content-Type is never read as an empty form, which would turn every rule off
+func policyBinding(c *gin.Context) binding.Binding {
+	switch c.ContentType() {
+	case binding.MIMEYAML, binding.MIMEYAML2, "text/yaml":
+		return binding.YAML
+	default:
+		return binding.JSON
+	}
+}
+
+// respondWithPolicyFormat writes the body as JSON or YAML
+func respondWithPolicyFormat(c *gin.Context, format string, status int, body any) {
+	if format == "yaml" {
+		c.YAML(status, body)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// notProtectedMessage reports a branch without protection
+func notProtectedMessage(branch string) string {
+	return fmt.Sprintf("Branch '%s' is not protected", branch)
+}
@@ -0,0 +1,294 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/api/routes"
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// serveProtections serves the branch protections of a repository on a fake GitHub, keyed by branch
+// A protection sent to GitHub replaces the current one
+func serveProtections(t *testing.T, fake *fakeGitHub, repo string, protections map[string]*github.Protection) {
+	var mu sync.Mutex
+
+	fake.handle("GET /repos/owner/"+repo+"/branches/{branch}/protection", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if protection := protections[r.PathValue("branch")]; protection != nil {
+			writeJSON(w, http.StatusOK, protection)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Branch not protected"})
+	})
+	fake.handle("PUT /repos/owner/"+repo+"/branches/{branch}/protection", func(w http.ResponseWriter, r *http.Request) {
+		var req github.ProtectionRequest
+		readJSON(t, r, &req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		protections[r.PathValue("branch")] = protectionFromRequest(req)
+		writeJSON(w, http.StatusOK, protections[r.PathValue("branch")])
+	})
+}
+
+// protectionFromRequest builds the protection GitHub reports after applying the request
+func protectionFromRequest(req github.ProtectionRequest) *github.Protection {
+	protection := &github.Protection{
+		EnforceAdmins:        &github.AdminEnforcement{Enabled: req.EnforceAdmins},
+		RequireLinearHistory: &github.RequireLinearHistory{Enabled: req.GetRequireLinearHistory()},
+		AllowForcePushes:     &github.AllowForcePushes{Enabled: req.GetAllowForcePushes()},
+		AllowDeletions:       &github.AllowDeletions{Enabled: req.GetAllowDeletions()},
+		RequiredStatusChecks: req.RequiredStatusChecks,
+	}
+	if reviews := req.RequiredPullRequestReviews; reviews != nil {
+		protection.RequiredPullRequestReviews = &github.PullRequestReviewsEnforcement{
+			RequiredApprovingReviewCount: reviews.RequiredApprovingReviewCount,
+			RequireCodeOwnerReviews:      reviews.RequireCodeOwnerReviews,
+			DismissStaleReviews:          reviews.DismissStaleReviews,
+			RequireLastPushApproval:      reviews.GetRequireLastPushApproval(),
+		}
+	}
+	if restrictions := req.Restrictions; restrictions != nil {
+		protection.Restrictions = &github.BranchRestrictions{}
+		for _, login := range restrictions.Users {
+			protection.Restrictions.Users = append(protection.Restrictions.Users, &github.User{Login: github.Ptr(login)})
+		}
+	}
+	return protection
+}
+
+func TestBranchProtection(t *testing.T) {
+	// newFakeClient serves 'test-repo' with a protected 'main' and an unprotected 'develop'
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application, map[string]*github.Protection) {
+		protections := map[string]*github.Protection{
+			"main": {
+				RequiredStatusChecks:       &github.RequiredStatusChecks{Strict: true, Contexts: &[]string{"ci/lint", "ci/build"}},
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequiredApprovingReviewCount: 1},
+				EnforceAdmins:              &github.AdminEnforcement{Enabled: true},
+				Restrictions:               &github.BranchRestrictions{Users: []*github.User{{Login: github.Ptr("release-bot")}}},
+			},
+		}
+
+		fake, app := newFakeGitHub(t)
+		serveProtections(t, fake, "test-repo", protections)
+		return fake, app, protections
+	}
+
+	putPolicy := func(t *testing.T, app *handlers.Application, url, contentType, body string) (*httptest.ResponseRecorder, models.BranchProtectionResponse) {
+		gin.SetMode(gin.TestMode)
+
+		r := gin.Default()
+		ghClient := handlers.GetClientForTest(app)
+		routes.SetupRoutes(r, *ghClient)
+
+		req, err := http.NewRequest("PUT", url, bytes.NewBufferString(body))
+		assert.NoError(t, err, errRequestCreate)
+		req.Header.Set("Content-Type", contentType)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var response models.BranchProtectionResponse
+		if w.Code == http.StatusOK {
+			err = json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	fields := func(changes []models.ProtectionChange) []string {
+		var f []string
+		for _, change := range changes {
+			f = append(f, change.Field)
+		}
+		return f
+	}
+
+	currentPolicy := `{
+		"required_reviews": {"required_approving_review_count": 1},
+		"required_status_checks": {"strict": true, "contexts": ["ci/build", "ci/lint"]},
+		"enforce_admins": true
+	}`
+
+	t.Run("Get the protection as a policy", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches/main/protection", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.BranchProtectionPolicy
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 1, response.RequiredReviews.RequiredApprovingReviewCount, "1 approval should be required")
+		assert.Equal(t, []string{"ci/build", "ci/lint"}, response.RequiredStatusChecks.Contexts, "Contexts should be sorted")
+		assert.True(t, response.EnforceAdmins, "Admins should be enforced")
+	})
+
+	t.Run("Get the protection as YAML", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches/main/protection?format=yaml", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.BranchProtectionPolicy
+		err := yaml.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, "Response should be YAML")
+		assert.True(t, response.RequiredStatusChecks.Strict, "Strict status checks should be read from YAML")
+	})
+
+	t.Run("Unprotected branch", func(t *testing.T) {
+		_, app, _ := newFakeClient(t)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/branches/develop/protection", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+
+	t.Run("Applying the current policy changes nothing", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t)
+
+		w, response := putPolicy(t, app, "/repositories/test-repo/branches/main/protection", "application/json", currentPolicy)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.False(t, response.Applied, "Nothing should be applied")
+		assert.Empty(t, response.Changes, "There should be no changes")
+		assert.False(t, fake.called("PUT /repos/owner/test-repo/branches/main/protection"), "Protection should not be replaced")
+	})
+
+	t.Run("Apply a YAML policy", func(t *testing.T) {
+		_, app, protections := newFakeClient(t)
+		policy := `
+required_reviews:
+  required_approving_review_count: 2
+  require_code_owner_reviews: true
+required_status_checks:
+  strict: true
+  contexts: [ci/lint, ci/build]
+require_linear_history: true
+enforce_admins: true
+`
+
+		w, response := putPolicy(t, app, "/repositories/test-repo/branches/main/protection", "application/yaml", policy)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, response.Applied, "Policy should be applied")
+		assert.Equal(t, []string{
+			"required_reviews.required_approving_review_count",
+			"required_reviews.require_code_owner_reviews",
+			"require_linear_history",
+		}, fields(response.Changes), "Only the changed fields should be listed")
+		assert.Equal(t, 2, response.Policy.RequiredReviews.RequiredApprovingReviewCount, "2 approvals should now be required")
+
+		protection := protections["main"]
+		assert.True(t, protection.GetRequireLinearHistory().Enabled, "Linear history should be required")
+		assert.Equal(t, "release-bot", protection.GetRestrictions().Users[0].GetLogin(), "Push restrictions should be kept")
+	})
+
+	t.Run("Protect a branch on a dry run", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t)
+
+		w, response := putPolicy(t, app, "/repositories/test-repo/branches/develop/protection?dry_run=true", "application/json", `{"allow_deletions": false, "required_reviews": {"required_approving_review_count": 1}}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.False(t, response.Applied, "Nothing should be applied on a dry run")
+		assert.Equal(t, []string{"protected", "required_reviews"}, fields(response.Changes), "Protection and reviews should be added")
+		assert.Nil(t, response.Policy, "Branch should still be unprotected")
+		assert.False(t, fake.called("PUT /repos/owner/test-repo/branches/develop/protection"), "Protection should not be created")
+	})
+
+	t.Run("Invalid policies", func(t *testing.T) {
+		for _, body := range []string{"", `{"required_reviews": {"required_approving_review_count": 7}}`, `{"required_status_checks": {"contexts": [""]}}`} {
+			// Nothing is registered, invalid policies must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w, _ := putPolicy(t, app, "/repositories/test-repo/branches/main/protection", "application/json", body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for '%s'", body)
+		}
+	})
+
+	t.Run("Keep the settings the policy does not model", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusOK, testProtectionWithExtras())
+		fake.handle("PUT /repos/owner/api/branches/main/protection", func(w http.ResponseWriter, r *http.Request) {
+			assertExtrasKept(t, r)
+			writeJSON(w, http.StatusOK, testProtectionWithExtras())
+		})
+
+		body := `{
+			"required_reviews": {"required_approving_review_count": 2},
+			"required_status_checks": {"contexts": ["build", "lint"]},
+			"enforce_admins": true
+		}`
+		w := serveJSON(t, app, "PUT", "/repositories/api/branches/main/protection", body)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, fake.called("PUT /repos/owner/api/branches/main/protection"), "Policy should be applied")
+	})
+}
+
+// testProtectionWithExtras is a protection holding every setting the policy does not model
+func testProtectionWithExtras() *github.Protection {
+	actors := func() ([]*github.User, []*github.Team, []*github.App) {
+		return []*github.User{{Login: github.Ptr("alice")}}, []*github.Team{{Slug: github.Ptr("core")}}, []*github.App{{Slug: github.Ptr("deploy-bot")}}
+	}
+	dismissal, bypass, restrictions := &github.DismissalRestrictions{}, &github.BypassPullRequestAllowances{}, &github.BranchRestrictions{}
+	dismissal.Users, dismissal.Teams, dismissal.Apps = actors()
+	bypass.Users, bypass.Teams, bypass.Apps = actors()
+	restrictions.Users, restrictions.Teams, restrictions.Apps = actors()
+
+	return &github.Protection{
+		RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+			RequiredApprovingReviewCount: 1,
+			DismissalRestrictions:        dismissal,
+			BypassPullRequestAllowances:  bypass,
+		},
+		RequiredStatusChecks: &github.RequiredStatusChecks{
+			Contexts: &[]string{"build"},
+			Checks:   &[]*github.RequiredStatusCheck{{Context: "build", AppID: github.Ptr(int64(15368))}},
+		},
+		EnforceAdmins:                  &github.AdminEnforcement{Enabled: true},
+		Restrictions:                   restrictions,
+		RequiredConversationResolution: &github.RequiredConversationResolution{Enabled: true},
+		LockBranch:                     &github.LockBranch{Enabled: github.Ptr(true)},
+		BlockCreations:                 &github.BlockCreations{Enabled: github.Ptr(true)},
+		AllowForkSyncing:               &github.AllowForkSyncing{Enabled: github.Ptr(true)},
+	}
+}
+
+// assertExtrasKept checks a protection sent to GitHub still holds the settings of testProtectionWithExtras
+func assertExtrasKept(t *testing.T, r *http.Request) {
+	var req map[string]any
+	readJSON(t, r, &req)
+
+	actors := map[string]any{"users": []any{"alice"}, "teams": []any{"core"}, "apps": []any{"deploy-bot"}}
+	reviews, _ := req["required_pull_request_reviews"].(map[string]any)
+	assert.Equal(t, actors, reviews["dismissal_restrictions"], "Dismissal restrictions should be kept")
+	assert.Equal(t, actors, reviews["bypass_pull_request_allowances"], "Bypass allowances should be kept")
+	assert.Equal(t, actors, req["restrictions"], "Push restrictions should be kept")
+
+	checks, _ := req["required_status_checks"].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"context": "build", "app_id": float64(15368)},
+		map[string]any{"context": "lint"},
+	}, checks["checks"], "Checks should keep their app")
+
+	for _, setting := range []string{"required_conversation_resolution", "lock_branch", "block_creations", "allow_fork_syncing"} {
+		assert.Equal(t, true, req[setting], setting+" should be kept")
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// UpdatePullRequest changes the title, body, base branch, state or draft state of a pull request
+// The draft state goes through GraphQL, if it fails once the other fields are saved it is reported as a warning
+func (a *Application) UpdatePullRequest(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var req models.PullRequestUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	if req.IsEmpty() {
+		respondWithBadRequest(c, "Nothing to update")
+		return
+	}
+
+	ctx := context.Background()
+	var pr *github.PullRequest
+	edited := editsPullRequest(req)
+	if edited {
+		pr, _, err = a.githubClient.PullRequests.Edit(ctx, a.owner, repo, number, newPullRequestEdit(req))
+	} else {
+		pr, _, err = a.githubClient.PullRequests.Get(ctx, a.owner, repo, number)
+	}
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	var warnings []string
+	if req.Draft != nil && pr.GetDraft() != *req.Draft {
+		if err := a.setDraft(ctx, pr.GetNodeID(), *req.Draft); err != nil {
+			if !edited {
+				respondWithGitHubError(c, err)
+				return
+			}
+			warnings = append(warnings, fmt.Sprintf("failed to change the draft state: %v", err))
+		} else {
+			pr.Draft = req.Draft
+		}
+	}
+
+	response := newPullRequestResponse(pr)
+	response.Warnings = warnings
+
+	c.JSON(http.StatusOK, response)
+}
+
+// editsPullRequest reports whether the update changes fields saved through the REST API
+func editsPullRequest(req models.PullRequestUpdateRequest) bool {
+	return req.Title != nil || req.Body != nil || req.Base != nil || req.State != nil
+}
+
+// newPullRequestEdit converts the update into the pull request sent to GitHub, unset fields are left out
+func newPullRequestEdit(req models.PullRequestUpdateRequest) *github.PullRequest {
+	edit := &github.PullRequest{
+		Title: req.Title,
+		Body:  req.Body,
+		State: req.State,
+	}
+	if req.Base != nil {
+		edit.Base = &github.PullRequestBranch{Ref: req.Base}
+	}
+	return edit
+}
+
+// ClosePullRequests comments on and closes every open pull request matching the query filters
+// Pull requests are handled one at a time to stay clear of GitHub's secondary rate limits on writes
+func (a *Application) ClosePullRequests(c *gin.Context) {
+	repo := c.Param("repo")
+
+	filter, err := parseBulkCloseFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var req models.BulkCloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	prs, truncated, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		prs, resp, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, filter.listOptions(a.owner, listOpts))
+		return filter.apply(prs), resp, err
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+	setTruncatedHeader(c, truncated)
+
+	errs := make([]error, len(prs))
+	if !req.DryRun {
+		for i, pr := range prs {
+			errs[i] = a.closePullRequest(ctx, repo, pr.GetNumber(), req.Comment)
+		}
+	}
+
+	c.JSON(http.StatusOK, newBulkCloseResponse(prs, errs, req.DryRun))
+}
+
+// closePullRequest posts the comment then closes the pull request
+// The pull request is left open when the comment cannot be posted
+func (a *Application) closePullRequest(ctx context.Context, repo string, number int, comment string) error {
+	// Pull requests share their number with an issue, which holds the conversation
+	_, _, err := a.githubClient.Issues.CreateComment(ctx, a.owner, repo, number, &github.IssueComment{Body: github.Ptr(comment)})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = a.githubClient.PullRequests.Edit(ctx, a.owner, repo, number, &github.PullRequest{State: github.Ptr("closed")})
+	return err
+}
+
+// parseBulkCloseFilter reads the pull request filters of a bulk close
+// At least one filter besides the state is required so a bare call cannot close every pull request
+func parseBulkCloseFilter(c *gin.Context) (prFilter, error) {
+	filter, err := parsePRFilter(c)
+	if err != nil {
+		return prFilter{}, err
+	}
+	if filter.State != "open" {
+		return prFilter{}, errors.New("Only open pull requests can be closed")
+	}
+
+	// State and ordering do not narrow the selection
+	criteria := filter
+	criteria.State, criteria.Sort, criteria.Direction = "", "", ""
+	if criteria == (prFilter{}) {
+		return prFilter{}, errors.New("At least one filter is required to close pull requests")
+	}
+	return filter, nil
+}
+
+// newBulkCloseResponse splits the matched pull requests into closed and failed ones
+func newBulkCloseResponse(prs []*github.PullRequest, errs []error, dryRun bool) models.BulkCloseResponse {
+	response := models.BulkCloseResponse{
+		DryRun:  dryRun,
+		Matched: len(prs),
+		Closed:  []int{},
+	}
+	for i, pr := range prs {
+		if errs[i] != nil {
+			_, body := translateGitHubError(errs[i])
+			response.Failed = append(response.Failed, models.PullRequestError{Number: pr.GetNumber(), Error: body.Error, Code: body.Code})
+			continue
+		}
+		response.Closed = append(response.Closed, pr.GetNumber())
+	}
+	return response
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// CreatePullRequest opens a pull request then requests reviews, assigns and labels it
+func (a *Application) CreatePullRequest(c *gin.Context) {
+	repo := c.Param("repo")
+
+	var req models.PullRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	pr, _, err := a.githubClient.PullRequests.Create(ctx, a.owner, repo, newPullRequestFromRequest(req))
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// The pull request exists at this point, so follow-up failures are reported as warnings
+	var warnings []string
+
+	if len(req.Reviewers) > 0 || len(req.TeamReviewers) > 0 {
+		reviewers := github.ReviewersRequest{Reviewers: req.Reviewers, TeamReviewers: req.TeamReviewers}
+		updated, _, err := a.githubClient.PullRequests.RequestReviewers(ctx, a.owner, repo, pr.GetNumber(), reviewers)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to request reviewers: %v", err))
+		} else {
+			pr.RequestedReviewers = updated.RequestedReviewers
+		}
+	}
+
+	// Pull requests share their number with an issue, which holds the assignees and labels
+	if len(req.Assignees) > 0 {
+		issue, _, err := a.githubClient.Issues.AddAssignees(ctx, a.owner, repo, pr.GetNumber(), req.Assignees)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to add assignees: %v", err))
+		} else {
+			pr.Assignees = issue.Assignees
+		}
+	}
+
+	if len(req.Labels) > 0 {
+		labels, _, err := a.githubClient.Issues.AddLabelsToIssue(ctx, a.owner, repo, pr.GetNumber(), req.Labels)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to add labels: %v", err))
+		} else {
+			pr.Labels = labels
+		}
+	}
+
+	response := newPullRequestResponse(pr)
+	response.Warnings = warnings
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// newPullRequestFromRequest converts the request into the pull request sent to GitHub
+func newPullRequestFromRequest(req models.PullRequestRequest) *github.NewPullRequest {
+	return &github.NewPullRequest{
+		Title:               github.Ptr(req.Title),
+		Body:                github.Ptr(req.Body),
+		Head:                github.Ptr(req.Head),
+		Base:                github.Ptr(req.Base),
+		Draft:               github.Ptr(req.Draft),
+		MaintainerCanModify: req.MaintainerCanModify,
+	}
+}
+
+// ListPullRequests fetches the PRs of a given repository, only open ones unless a state is given
+// Every page is fetched up to the configured cap unless the client asks for a specific page
+func (a *Application) ListPullRequests(c *gin.Context) {
+	repo := c.Param("repo") // Get repository name from URL parameter
+
+	// Get the 'limit' query parameter if provided
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		respondWithBadRequest(c, "Invalid limit parameter")
+		return
+	}
+
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	filter, err := parsePRFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	// Filtering each page keeps the limit and the cap counting matching PRs only
+	fetch := func(listOpts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		pullRequests, resp, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, filter.listOptions(a.owner, listOpts))
+		return filter.apply(pullRequests), resp, err
+	}
+
+	pullRequests, err := fetchPagesWithLimit(c, p, a.maxResults, limit, fetch)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Apply limit if it's greater than 0 and less than the total number of PRs
+	if limit > 0 && limit < len(pullRequests) {
+		pullRequests = pullRequests[:limit]
+	}
+
+	c.JSON(http.StatusOK, newPullRequestResponses(pullRequests))
+}
+
+// GetPullRequest returns a single PR with its files, commits, review summary and check status
+// The GitHub calls run concurrently, the checks start as soon as the head commit is known
+func (a *Application) GetPullRequest(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+
+	var (
+		wg               sync.WaitGroup
+		pr               *github.PullRequest
+		checks           map[string]string
+		files            []*github.CommitFile
+		commits          []*github.RepositoryCommit
+		reviews          []*github.PullRequestReview
+		filesTruncated   bool
+		commitsTruncated bool
+		prErr            error
+		filesErr         error
+		commitsErr       error
+		reviewsErr       error
+	)
+
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		pr, _, prErr = a.githubClient.PullRequests.Get(ctx, a.owner, repo, number)
+		if prErr == nil {
+			checks, prErr = a.headCheckStates(ctx, a.owner, repo, pr.GetHead().GetSHA())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		files, filesTruncated, filesErr = fetchAllPages(maxPerPage, a.maxResults, func(opts github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+			return a.githubClient.PullRequests.ListFiles(ctx, a.owner, repo, number, &opts)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		commits, commitsTruncated, commitsErr = fetchAllPages(maxPerPage, a.maxResults, func(opts github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+			return a.githubClient.PullRequests.ListCommits(ctx, a.owner, repo, number, &opts)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		reviews, _, reviewsErr = fetchAllPages(maxPerPage, 0, func(opts github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+			return a.githubClient.PullRequests.ListReviews(ctx, a.owner, repo, number, &opts)
+		})
+	}()
+	wg.Wait()
+
+	for _, err := range []error{prErr, filesErr, commitsErr, reviewsErr} {
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+	}
+
+	setTruncatedHeader(c, filesTruncated || commitsTruncated)
+	c.JSON(http.StatusOK, newPullRequestDetail(pr, files, commits, reviews, checks))
+}
+
+// newPullRequestDetail builds the detail response from the data gathered from GitHub
+func newPullRequestDetail(pr *github.PullRequest, files []*github.CommitFile, commits []*github.RepositoryCommit, reviews []*github.PullRequestReview, checks map[string]string) models.PullRequestDetail {
+	detail := models.PullRequestDetail{
+		PullRequestResponse: newPullRequestResponse(pr),
+		Body:                pr.GetBody(),
+		Mergeable:           pr.Mergeable,
+		MergeableState:      pr.GetMergeableState(),
+		Additions:           pr.GetAdditions(),
+		Deletions:           pr.GetDeletions(),
+		ChangedFiles:        pr.GetChangedFiles(),
+		Files:               make([]models.PullRequestFile, 0, len(files)),
+		Commits:             make([]models.PullRequestCommit, 0, len(commits)),
+		Reviews:             latestReviewStates(reviews),
+		Checks:              newCheckSummary(checks),
+	}
+
+	for _, file := range files {
+		detail.Files = append(detail.Files, models.PullRequestFile{
+			Filename:         file.GetFilename(),
+			PreviousFilename: file.GetPreviousFilename(),
+			Status:           file.GetStatus(),
+			Additions:        file.GetAdditions(),
+			Deletions:        file.GetDeletions(),
+			Changes:          file.GetChanges(),
+		})
+	}
+
+	for _, commit := range commits {
+		// Prefer the GitHub login, fall back to the git author for unknown emails
+		author := commit.GetAuthor().GetLogin()
+		if author == "" {
+			author = commit.GetCommit().GetAuthor().GetName()
+		}
+		detail.Commits = append(detail.Commits, models.PullRequestCommit{
+			SHA:     commit.GetSHA(),
+			Message: commit.GetCommit().GetMessage(),
+			Author:  author,
+			Date:    commit.GetCommit().GetAuthor().GetDate().Time,
+		})
+	}
+
+	return detail
+}
+
+// newCheckSummary counts the checks by state, sorted by name
+func newCheckSummary(checks map[string]string) models.CheckSummary {
+	summary := models.CheckSummary{State: "none", Checks: make([]models.CheckState, 0, len(checks))}
+	for name, state := range checks {
+		summary.Checks = append(summary.Checks, models.CheckState{Name: name, State: state})
+		switch state {
+		case checkSuccess:
+			summary.Success++
+		case checkPending:
+			summary.Pending++
+		default:
+			summary.Failure++
+		}
+	}
+	sort.Slice(summary.Checks, func(i, j int) bool { return summary.Checks[i].Name < summary.Checks[j].Name })
+
+	summary.Total = len(checks)
+	switch {
+	case summary.Failure > 0:
+		summary.State = checkFailure
+	case summary.Pending > 0:
+		summary.State = checkPending
+	case summary.Success > 0:
+		summary.State = checkSuccess
+	}
+	return summary
+}
+
+// ListAccountPullRequests lists the PRs of every repository owned by the configured owner in a single list
+// Repositories are queried in parallel, failures are reported per repository instead of failing the request
+func (a *Application) ListAccountPullRequests(c *gin.Context) {
+	// Get the 'limit' query parameter if provided
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		respondWithBadRequest(c, "Invalid limit parameter")
+		return
+	}
+
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	filter, err := parsePRFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	ownerRepos := repoFilter{Type: "owner", Sort: "full_name"}
+	repos, _, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return a.githubClient.Repositories.ListByAuthenticatedUser(ctx, ownerRepos.listOptions(listOpts))
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// No repository can contribute more than the limit or the cap
+	maxResults := a.maxResults
+	if limit > 0 && limit < maxResults {
+		maxResults = limit
+	}
+
+	results, errs := fanOut(repos, fanOutConcurrency, func(repo *github.Repository) ([]*github.PullRequest, error) {
+		prs, _, err := fetchAllPages(maxPerPage, maxResults, func(listOpts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+			prs, resp, err := a.githubClient.PullRequests.List(ctx, a.owner, repo.GetName(), filter.listOptions(a.owner, listOpts))
+			return filter.apply(prs), resp, err
+		})
+		return prs, err
+	})
+
+	pullRequests, repoErrors := collectRepoResults(repos, results, errs)
+	respondWithPullRequestList(c, p, limit, a.maxResults, filter, pullRequests, repoErrors)
+}
+
+// respondWithPullRequestList sorts the merged pull requests, applies the cap, the limit and the requested page
+func respondWithPullRequestList(c *gin.Context, p pagination, limit, maxResults int, filter prFilter, pullRequests []*github.PullRequest, repoErrors []models.RepoError) {
+	sortPullRequests(pullRequests, filter)
+
+	if maxResults > 0 && len(pullRequests) > maxResults && (limit == 0 || limit > maxResults) {
+		setTruncatedHeader(c, true)
+		pullRequests = pullRequests[:maxResults]
+	}
+	if limit > 0 && limit < len(pullRequests) {
+		pullRequests = pullRequests[:limit]
+	}
+
+	if p.Page > 0 {
+		var nextPage, lastPage int
+		pullRequests, nextPage, lastPage = paginateSlice(pullRequests, p)
+		setPaginationHeaders(c, p.Page, nextPage, lastPage)
+	}
+
+	c.JSON(http.StatusOK, models.PullRequestList{
+		PullRequests: newPullRequestResponses(pullRequests),
+		Errors:       repoErrors,
+	})
+}
+
+// newPullRequestResponse converts a GitHub pull request into the simplified format
+func newPullRequestResponse(pr *github.PullRequest) models.PullRequestResponse {
+	response := models.PullRequestResponse{
+		Repository: pr.GetBase().GetRepo().GetName(),
+		Title:      pr.GetTitle(),
+		Number:     pr.GetNumber(),
+		User:       pr.GetUser().GetLogin(),
+		State:      pr.GetState(),
+		Draft:      pr.GetDraft(),
+		Base:       pr.GetBase().GetRef(),
+		Head:       pr.GetHead().GetRef(),
+		Labels:     labelNames(pr.Labels),
+		Assignees:  userLogins(pr.Assignees),
+		Reviewers:  userLogins(pr.RequestedReviewers),
+		CreatedAt:  pr.GetCreatedAt().Time,
+		UpdatedAt:  pr.GetUpdatedAt().Time,
+		HtmlURL:    pr.GetHTMLURL(),
+	}
+
+	if pr.MergedAt != nil {
+		response.State = "merged"
+		response.MergedAt = &pr.MergedAt.Time
+	}
+	return response
+}
+
+// newPullRequestResponses converts a list of GitHub pull requests
+func newPullRequestResponses(pullRequests []*github.PullRequest) []models.PullRequestResponse {
+	formattedPRs := make([]models.PullRequestResponse, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		formattedPRs = append(formattedPRs, newPullRequestResponse(pr))
+	}
+	return formattedPRs
+}
+
+// userLogins returns the login of every user
+func userLogins(users []*github.User) []string {
+	var logins []string
+	for _, user := range users {
+		logins = append(logins, user.GetLogin())
+	}
+	return logins
+}
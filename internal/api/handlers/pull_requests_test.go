@@ -0,0 +1,910 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPullRequest builds a pull request on 'test-repo' merging head into main
+func testPullRequest(number int, state, author, head string, createdAt time.Time) *github.PullRequest {
+	return &github.PullRequest{
+		Number:    github.Ptr(number),
+		Title:     github.Ptr("PR " + head),
+		State:     github.Ptr(state),
+		User:      &github.User{Login: github.Ptr(author)},
+		CreatedAt: &github.Timestamp{Time: createdAt},
+		UpdatedAt: &github.Timestamp{Time: createdAt},
+		Head:      &github.PullRequestBranch{Ref: github.Ptr(head), Label: github.Ptr("test:" + head)},
+		Base: &github.PullRequestBranch{
+			Ref:  github.Ptr("main"),
+			Repo: &github.Repository{Name: github.Ptr("test-repo")},
+		},
+	}
+}
+
+func TestListPullRequestFilters(t *testing.T) {
+	created := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	feature := testPullRequest(1, "open", "alice", "feature", created)
+	feature.Labels = []*github.Label{{Name: github.Ptr("enhancement")}}
+
+	draft := testPullRequest(2, "open", "bob", "wip", created.AddDate(0, 0, 5))
+	draft.Draft = github.Ptr(true)
+
+	merged := testPullRequest(3, "closed", "alice", "fix", created.AddDate(0, 0, -5))
+	merged.MergedAt = &github.Timestamp{Time: created}
+
+	closed := testPullRequest(4, "closed", "bob", "abandoned", created.AddDate(0, 0, -10))
+
+	// listPRs lists the pull requests of 'api' from a GitHub filtering on state and head like the real one
+	listPRs := func(t *testing.T, query string) (*httptest.ResponseRecorder, []models.PullRequestResponse) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /repos/owner/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+			state, head := r.URL.Query().Get("state"), r.URL.Query().Get("head")
+			prs := []*github.PullRequest{}
+			for _, pr := range []*github.PullRequest{feature, draft, merged, closed} {
+				if (state == "all" || pr.GetState() == state) && (head == "" || head == "owner:"+pr.GetHead().GetRef()) {
+					prs = append(prs, pr)
+				}
+			}
+			writeJSON(w, http.StatusOK, prs)
+		})
+
+		w := serveJSON(t, app, "GET", "/repositories/api/pull-requests?"+query, "")
+
+		var response []models.PullRequestResponse
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	t.Run("Open pull requests by default", func(t *testing.T) {
+		w, response := listPRs(t, "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1, 2}, numbersOf(response), "Only open PRs should be listed")
+		assert.Equal(t, "feature", response[0].Head, "Head branch should match")
+		assert.Equal(t, "main", response[0].Base, "Base branch should match")
+		assert.Equal(t, []string{"enhancement"}, response[0].Labels, "Labels should match")
+		assert.True(t, response[1].Draft, "Second PR should be a draft")
+	})
+
+	t.Run("Merged pull requests", func(t *testing.T) {
+		w, response := listPRs(t, "state=merged")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{3}, numbersOf(response), "Only the merged PR should be listed")
+		assert.Equal(t, "merged", response[0].State, "State should be 'merged'")
+		assert.NotNil(t, response[0].MergedAt, "Merge date should be set")
+	})
+
+	t.Run("Filter by author and state", func(t *testing.T) {
+		w, response := listPRs(t, "state=all&author=ALICE")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1, 3}, numbersOf(response), "Only alice's PRs should be listed")
+	})
+
+	t.Run("Filter by label, head and draft", func(t *testing.T) {
+		w, response := listPRs(t, "label=enhancement")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1}, numbersOf(response), "Only the labelled PR should be listed")
+
+		w, response = listPRs(t, "head=wip")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{2}, numbersOf(response), "Only the PR from 'wip' should be listed")
+
+		w, response = listPRs(t, "draft=false")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1}, numbersOf(response), "Only the ready PR should be listed")
+	})
+
+	t.Run("Filter by creation date", func(t *testing.T) {
+		w, response := listPRs(t, "state=all&created_since=2025-01-01&created_until=2025-01-12T00:00:00Z")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1, 3}, numbersOf(response), "Only PRs created in range should be listed")
+	})
+
+	t.Run("Invalid filters", func(t *testing.T) {
+		for _, query := range []string{"state=draft", "sort=name", "draft=maybe", "created_since=yesterday"} {
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "GET", "/repositories/api/pull-requests?"+query, "")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for "+query)
+		}
+	})
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	created := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	request := `{
+		"title": "Release 1.2",
+		"head": "release/1.2",
+		"base": "main",
+		"draft": true,
+		"reviewers": ["alice"],
+		"assignees": ["bob"],
+		"labels": ["release"]
+	}`
+
+	// newFakeClient serves the creation of PR #1 and the follow-up calls setting its reviewers, assignees and labels
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /repos/owner/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+			var pr github.NewPullRequest
+			readJSON(t, r, &pr)
+			assert.Equal(t, "release/1.2", pr.GetHead(), "Head branch should be sent")
+			assert.True(t, pr.GetDraft(), "Draft flag should be sent")
+
+			opened := testPullRequest(1, "open", "owner", pr.GetHead(), created)
+			opened.Title, opened.Draft = pr.Title, pr.Draft
+			writeJSON(w, http.StatusCreated, opened)
+		})
+		fake.handle("POST /repos/owner/api/pulls/1/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+			var reviewers github.ReviewersRequest
+			readJSON(t, r, &reviewers)
+			assert.Equal(t, []string{"alice"}, reviewers.Reviewers, "Reviewers should be requested")
+
+			writeJSON(w, http.StatusCreated, github.PullRequest{RequestedReviewers: []*github.User{{Login: github.Ptr("alice")}}})
+		})
+		fake.reply("POST /repos/owner/api/issues/1/assignees", http.StatusCreated, github.Issue{Assignees: []*github.User{{Login: github.Ptr("bob")}}})
+		return fake, app
+	}
+
+	t.Run("Successfully create a pull request", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("POST /repos/owner/api/issues/1/labels", http.StatusOK, []*github.Label{{Name: github.Ptr("release")}})
+
+		w := serveJSON(t, app, "POST", "/repositories/api/pull-requests", request)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 1, response.Number, "PR number should be 1")
+		assert.Equal(t, "Release 1.2", response.Title, "PR title should match")
+		assert.Equal(t, "open", response.State, "PR should be open")
+		assert.True(t, response.Draft, "PR should be a draft")
+		assert.Equal(t, "release/1.2", response.Head, "Head branch should match")
+		assert.Equal(t, "main", response.Base, "Base branch should match")
+		assert.Equal(t, []string{"alice"}, response.Reviewers, "Reviewers should match")
+		assert.Equal(t, []string{"bob"}, response.Assignees, "Assignees should match")
+		assert.Equal(t, []string{"release"}, response.Labels, "Labels should match")
+		assert.Empty(t, response.Warnings, "Nothing should have failed")
+	})
+
+	t.Run("Follow-up failures are warnings", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("POST /repos/owner/api/issues/1/labels", http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+
+		w := serveJSON(t, app, "POST", "/repositories/api/pull-requests", request)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created since the PR exists")
+
+		var response models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response.Warnings, 1, "Label failure should be reported as a warning")
+		assert.Empty(t, response.Labels, "Labels should not be reported as set")
+		assert.Equal(t, []string{"bob"}, response.Assignees, "Assignees should still be set")
+	})
+
+	t.Run("Missing required fields", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/api/pull-requests", `{"title": "No branches"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Head and base are the same branch", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("POST /repos/owner/api/pulls", http.StatusUnprocessableEntity, map[string]any{
+			"message": "Validation Failed",
+			"errors":  []map[string]string{{"resource": "PullRequest", "code": "custom", "message": "No commits between main and main"}},
+		})
+
+		w := serveJSON(t, app, "POST", "/repositories/api/pull-requests", `{"title": "Nothing", "head": "main", "base": "main"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Repository does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("POST /repos/owner/missing/pulls", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w := serveJSON(t, app, "POST", "/repositories/missing/pull-requests", `{"title": "PR", "head": "feature", "base": "main"}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
+
+func TestMergePullRequest(t *testing.T) {
+	newPR := func() *github.PullRequest {
+		pr := testPullRequest(1, "open", "alice", "feature", time.Now())
+		pr.Head.SHA = github.Ptr("abc123")
+		pr.Mergeable = github.Ptr(true)
+		return pr
+	}
+
+	protection := &github.Protection{
+		RequiredStatusChecks:       &github.RequiredStatusChecks{Contexts: &[]string{"ci/build", "ci/lint"}},
+		RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequiredApprovingReviewCount: 1},
+	}
+
+	// mergeState is what GitHub holds about the pull request besides the pull request itself
+	type mergeState struct {
+		protection *github.Protection // nil when main is not protected
+		statuses   []*github.RepoStatus
+		runs       []*github.CheckRun
+		reviews    []*github.PullRequestReview
+	}
+
+	// newFakeClient serves PR #1 of 'api' along with its merge checks, merging it when asked
+	newFakeClient := func(t *testing.T, pr *github.PullRequest, state mergeState) (*fakeGitHub, *handlers.Application) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls/1", http.StatusOK, pr)
+		if state.protection != nil {
+			fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusOK, state.protection)
+		} else {
+			fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusNotFound, map[string]string{"message": "Branch not protected"})
+		}
+		fake.reply("GET /repos/owner/api/commits/abc123/status", http.StatusOK, github.CombinedStatus{Statuses: state.statuses})
+		fake.reply("GET /repos/owner/api/commits/abc123/check-runs", http.StatusOK, github.ListCheckRunsResults{CheckRuns: state.runs})
+		fake.reply("GET /repos/owner/api/pulls/1/reviews", http.StatusOK, state.reviews)
+		fake.handle("PUT /repos/owner/api/pulls/1/merge", func(w http.ResponseWriter, r *http.Request) {
+			var merge map[string]string
+			readJSON(t, r, &merge)
+			writeJSON(w, http.StatusOK, github.PullRequestMergeResult{SHA: github.Ptr("merge-" + merge["merge_method"]), Merged: github.Ptr(true)})
+		})
+		return fake, app
+	}
+
+	mergePR := func(t *testing.T, app *handlers.Application, number, body string) (*httptest.ResponseRecorder, models.ErrorResponse) {
+		w := serveJSON(t, app, "PUT", "/repositories/api/pull-requests/"+number+"/merge", body)
+
+		var response models.ErrorResponse
+		if w.Code != http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	reasons := func(blockers []models.MergeBlocker) []string {
+		var r []string
+		for _, blocker := range blockers {
+			r = append(r, blocker.Reason)
+		}
+		return r
+	}
+
+	status := func(context, state string) *github.RepoStatus {
+		return &github.RepoStatus{Context: github.Ptr(context), State: github.Ptr(state)}
+	}
+	review := func(login, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.Ptr(login)}, State: github.Ptr(state)}
+	}
+
+	t.Run("Successfully merge a pull request", func(t *testing.T) {
+		fake, app := newFakeClient(t, newPR(), mergeState{})
+
+		w, _ := mergePR(t, app, "1", `{"method": "squash", "sha": "abc123"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.MergeResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "squash", response.Method, "Merge method should be 'squash'")
+		assert.Equal(t, "merge-squash", response.SHA, "Merge commit SHA should be returned")
+		assert.True(t, fake.called("PUT /repos/owner/api/pulls/1/merge"), "PR should be merged")
+	})
+
+	t.Run("Merge without a body", func(t *testing.T) {
+		_, app := newFakeClient(t, newPR(), mergeState{})
+
+		w, _ := mergePR(t, app, "1", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Contains(t, w.Body.String(), "merge-merge", "Merge method should default to 'merge'")
+	})
+
+	t.Run("Hidden branch protection is left to the merge", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls/1", http.StatusOK, newPR())
+		fake.reply("GET /repos/owner/api/branches/main/protection", http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+		fake.reply("GET /repos/owner/api/commits/abc123/status", http.StatusOK, github.CombinedStatus{})
+		fake.reply("GET /repos/owner/api/commits/abc123/check-runs", http.StatusOK, github.ListCheckRunsResults{})
+		fake.reply("GET /repos/owner/api/pulls/1/reviews", http.StatusOK, []*github.PullRequestReview{})
+		fake.reply("PUT /repos/owner/api/pulls/1/merge", http.StatusOK, github.PullRequestMergeResult{SHA: github.Ptr("def456")})
+
+		w, _ := mergePR(t, app, "1", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+	})
+
+	t.Run("Draft with conflicts and a moved head", func(t *testing.T) {
+		pr := newPR()
+		pr.Draft = github.Ptr(true)
+		pr.Mergeable = github.Ptr(false)
+		fake, app := newFakeClient(t, pr, mergeState{})
+
+		w, response := mergePR(t, app, "1", `{"sha": "def456"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.Equal(t, models.ErrCodeMergeBlocked, response.Code, "Code should be 'merge_blocked'")
+		assert.Equal(t, []string{models.MergeBlockerDraft, models.MergeBlockerHeadChanged, models.MergeBlockerConflicts}, reasons(response.Blockers), "Every blocker should be listed")
+		assert.False(t, fake.called("PUT /repos/owner/api/pulls/1/merge"), "PR should not be merged")
+	})
+
+	t.Run("Required checks and approvals missing", func(t *testing.T) {
+		_, app := newFakeClient(t, newPR(), mergeState{
+			protection: protection,
+			statuses:   []*github.RepoStatus{status("ci/build", "failure")},
+		})
+
+		w, response := mergePR(t, app, "1", "")
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.Equal(t, []string{models.MergeBlockerStatusCheck, models.MergeBlockerStatusCheck, models.MergeBlockerApprovals}, reasons(response.Blockers), "Every blocker should be listed")
+		assert.Equal(t, "ci/build", response.Blockers[0].Context, "Failing check should be named")
+		assert.Equal(t, "ci/lint", response.Blockers[1].Context, "Missing check should be named")
+	})
+
+	t.Run("Only the latest review of each reviewer counts", func(t *testing.T) {
+		_, app := newFakeClient(t, newPR(), mergeState{
+			protection: protection,
+			statuses:   []*github.RepoStatus{status("ci/build", "success")},
+			// Neutral check runs pass like on GitHub
+			runs: []*github.CheckRun{{Name: github.Ptr("ci/lint"), Status: github.Ptr("completed"), Conclusion: github.Ptr("neutral")}},
+			reviews: []*github.PullRequestReview{
+				review("bob", "CHANGES_REQUESTED"),
+				review("bob", "APPROVED"),
+				review("carol", "CHANGES_REQUESTED"),
+				review("carol", "COMMENTED"),
+			},
+		})
+
+		w, response := mergePR(t, app, "1", "")
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.Equal(t, []string{models.MergeBlockerChangesRequested}, reasons(response.Blockers), "Only carol's change request should block")
+		assert.Equal(t, "carol", response.Blockers[0].Context, "Reviewer requesting changes should be named")
+	})
+
+	t.Run("Already merged", func(t *testing.T) {
+		pr := newPR()
+		pr.State = github.Ptr("closed")
+		pr.MergedAt = &github.Timestamp{Time: time.Now()}
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls/1", http.StatusOK, pr)
+
+		w, response := mergePR(t, app, "1", "")
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.Equal(t, []string{models.MergeBlockerAlreadyMerged}, reasons(response.Blockers), "PR should be reported as merged")
+	})
+
+	t.Run("Invalid merge method", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w, _ := mergePR(t, app, "1", `{"method": "octopus"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Pull request does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls/2", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w, _ := mergePR(t, app, "2", "")
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+
+		w, _ = mergePR(t, app, "abc", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+}
+
+func TestGetPullRequest(t *testing.T) {
+	files := []*github.CommitFile{
+		{Filename: github.Ptr("main.go"), Status: github.Ptr("modified"), Additions: github.Ptr(10), Deletions: github.Ptr(2), Changes: github.Ptr(12)},
+		{Filename: github.Ptr("new.go"), PreviousFilename: github.Ptr("old.go"), Status: github.Ptr("renamed")},
+	}
+	commits := []*github.RepositoryCommit{
+		{SHA: github.Ptr("abc123"), Author: &github.User{Login: github.Ptr("alice")}, Commit: &github.Commit{Message: github.Ptr("Add feature")}},
+		{SHA: github.Ptr("def456"), Commit: &github.Commit{Message: github.Ptr("Fix typo"), Author: &github.CommitAuthor{Name: github.Ptr("Bob")}}},
+	}
+
+	// newFakeClient serves PR #1 of 'test-repo' with one pending status and one successful check run
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application) {
+		pr := testPullRequest(1, "open", "alice", "feature", time.Now())
+		pr.Head.SHA = github.Ptr("abc123")
+		pr.Body = github.Ptr("Adds the feature")
+		pr.Mergeable = github.Ptr(true)
+
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo/pulls/1", http.StatusOK, pr)
+		fake.reply("GET /repos/owner/test-repo/pulls/1/reviews", http.StatusOK, []*github.PullRequestReview{
+			{User: &github.User{Login: github.Ptr("bob")}, State: github.Ptr("APPROVED")},
+			{User: &github.User{Login: github.Ptr("carol")}, State: github.Ptr("COMMENTED")},
+			{User: &github.User{Login: github.Ptr("dave")}, State: github.Ptr("CHANGES_REQUESTED")},
+		})
+		fake.reply("GET /repos/owner/test-repo/commits/abc123/status", http.StatusOK, github.CombinedStatus{
+			Statuses: []*github.RepoStatus{{Context: github.Ptr("ci/test"), State: github.Ptr("pending")}},
+		})
+		fake.reply("GET /repos/owner/test-repo/commits/abc123/check-runs", http.StatusOK, github.ListCheckRunsResults{
+			CheckRuns: []*github.CheckRun{{Name: github.Ptr("ci/build"), Status: github.Ptr("completed"), Conclusion: github.Ptr("success")}},
+		})
+		return fake, app
+	}
+
+	getPR := func(t *testing.T, app *handlers.Application, number string) *httptest.ResponseRecorder {
+		return serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/"+number, "")
+	}
+
+	t.Run("Successfully get a pull request", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		fake.reply("GET /repos/owner/test-repo/pulls/1/files", http.StatusOK, files)
+		fake.reply("GET /repos/owner/test-repo/pulls/1/commits", http.StatusOK, commits)
+
+		w := getPR(t, app, "1")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Empty(t, w.Header().Get("X-Truncated"), "Response should not be truncated")
+
+		var response models.PullRequestDetail
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, 1, response.Number, "PR number should match")
+		assert.Equal(t, "Adds the feature", response.Body, "PR body should match")
+
+		assert.Len(t, response.Files, 2, "There should be 2 changed files")
+		assert.Equal(t, 10, response.Files[0].Additions, "Additions should match")
+		assert.Equal(t, "old.go", response.Files[1].PreviousFilename, "Previous filename should match")
+
+		assert.Len(t, response.Commits, 2, "There should be 2 commits")
+		assert.Equal(t, "alice", response.Commits[0].Author, "GitHub login should be used")
+		assert.Equal(t, "Bob", response.Commits[1].Author, "Git author should be used without a login")
+
+		assert.Equal(t, []models.ReviewerState{
+			{Reviewer: "bob", State: "APPROVED"},
+			{Reviewer: "carol", State: "COMMENTED"},
+			{Reviewer: "dave", State: "CHANGES_REQUESTED"},
+		}, response.Reviews, "Review summary should match")
+
+		assert.Equal(t, "pending", response.Checks.State, "Combined check state should be 'pending'")
+		assert.Equal(t, 2, response.Checks.Total, "There should be 2 checks")
+		assert.Equal(t, "ci/build", response.Checks.Checks[0].Name, "Checks should be sorted by name")
+	})
+
+	t.Run("Pull request without checks", func(t *testing.T) {
+		pr := testPullRequest(1, "open", "alice", "feature", time.Now())
+		pr.Head.SHA = github.Ptr("abc123")
+
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo/pulls/1", http.StatusOK, pr)
+		fake.reply("GET /repos/owner/test-repo/commits/abc123/status", http.StatusOK, github.CombinedStatus{})
+		fake.reply("GET /repos/owner/test-repo/commits/abc123/check-runs", http.StatusOK, github.ListCheckRunsResults{})
+		fake.reply("GET /repos/owner/test-repo/pulls/1/files", http.StatusOK, []*github.CommitFile{})
+		fake.reply("GET /repos/owner/test-repo/pulls/1/commits", http.StatusOK, []*github.RepositoryCommit{})
+		fake.reply("GET /repos/owner/test-repo/pulls/1/reviews", http.StatusOK, []*github.PullRequestReview{})
+
+		w := getPR(t, app, "1")
+
+		var response models.PullRequestDetail
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "none", response.Checks.State, "Combined check state should be 'none'")
+		assert.Empty(t, response.Files, "There should be no changed files")
+	})
+
+	t.Run("Files and commits are capped", func(t *testing.T) {
+		fake, app := newFakeClient(t)
+		app.SetMaxResults(1)
+		fake.replyPages("GET /repos/owner/test-repo/pulls/1/files", files[:1], files[1:])
+		fake.replyPages("GET /repos/owner/test-repo/pulls/1/commits", commits[:1], commits[1:])
+
+		w := getPR(t, app, "1")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "true", w.Header().Get("X-Truncated"), "Response should be marked as truncated")
+
+		var response models.PullRequestDetail
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response.Files, 1, "Files should be capped")
+		assert.Len(t, response.Commits, 1, "Commits should be capped")
+	})
+
+	t.Run("Pull request does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		notFound := map[string]string{"message": "Not Found"}
+		fake.reply("GET /repos/owner/test-repo/pulls/2", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/test-repo/pulls/2/files", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/test-repo/pulls/2/commits", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/test-repo/pulls/2/reviews", http.StatusNotFound, notFound)
+
+		w := getPR(t, app, "2")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
+
+func TestListAccountPullRequests(t *testing.T) {
+	created := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	first := testPullRequest(1, "open", "alice", "first", created)
+	second := testPullRequest(2, "open", "bob", "second", created.AddDate(0, 0, 2))
+	second.Base.Repo = &github.Repository{Name: github.Ptr("other-repo")}
+	third := testPullRequest(3, "open", "alice", "third", created.AddDate(0, 0, 1))
+	third.Base.Repo = &github.Repository{Name: github.Ptr("other-repo")}
+	closed := testPullRequest(4, "closed", "alice", "closed", created.AddDate(0, 0, 3))
+
+	// listPRs lists the pull requests across the repositories of 'owner', the pulls of 'broken-repo' fail
+	listPRs := func(t *testing.T, query string) (*httptest.ResponseRecorder, models.PullRequestList) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "owner", r.URL.Query().Get("type"), "Only repositories of the owner should be listed")
+			writeJSON(w, http.StatusOK, []*github.Repository{
+				{Name: github.Ptr("test-repo")},
+				{Name: github.Ptr("other-repo")},
+				{Name: github.Ptr("broken-repo")},
+			})
+		})
+		for _, repo := range []string{"test-repo", "other-repo"} {
+			fake.handle("GET /repos/owner/"+repo+"/pulls", func(w http.ResponseWriter, r *http.Request) {
+				state := r.URL.Query().Get("state")
+				prs := []*github.PullRequest{}
+				for _, pr := range []*github.PullRequest{first, second, third, closed} {
+					if pr.GetBase().GetRepo().GetName() == repo && (state == "all" || pr.GetState() == state) {
+						prs = append(prs, pr)
+					}
+				}
+				writeJSON(w, http.StatusOK, prs)
+			})
+		}
+		fake.reply("GET /repos/owner/broken-repo/pulls", http.StatusServiceUnavailable, map[string]string{"message": "Service Unavailable"})
+
+		w := serveJSON(t, app, "GET", "/pull-requests?"+query, "")
+
+		var response models.PullRequestList
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	t.Run("Merge open pull requests across repositories", func(t *testing.T) {
+		w, response := listPRs(t, "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{2, 3, 1}, numbersOf(response.PullRequests), "PRs should be sorted by creation, newest first")
+		assert.Equal(t, "other-repo", response.PullRequests[0].Repository, "PRs should be tagged with their repository")
+		assert.Equal(t, "test-repo", response.PullRequests[2].Repository, "PRs should be tagged with their repository")
+		assert.Len(t, response.Errors, 1, "Failing repository should be reported")
+		assert.Equal(t, "broken-repo", response.Errors[0].Repository, "Failing repository should be named")
+		assert.Equal(t, models.ErrCodeUpstreamError, response.Errors[0].Code, "Failure should carry its error code")
+	})
+
+	t.Run("Filters, sort and limit", func(t *testing.T) {
+		w, response := listPRs(t, "author=alice&state=all&direction=asc&limit=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1, 3}, numbersOf(response.PullRequests), "alice's oldest 2 PRs should be listed")
+	})
+
+	t.Run("Pagination over the merged list", func(t *testing.T) {
+		w, response := listPRs(t, "page=2&per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1}, numbersOf(response.PullRequests), "Second page should hold the last PR")
+		assert.Contains(t, w.Header().Get("Link"), `rel="prev"`, "Link header should point to the previous page")
+	})
+
+	t.Run("Invalid filters", func(t *testing.T) {
+		w, _ := listPRs(t, "state=draft")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+}
+
+func TestUpdatePullRequest(t *testing.T) {
+	created := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	newPR := func() *github.PullRequest {
+		pr := testPullRequest(1, "open", "alice", "feature", created)
+		pr.NodeID, pr.Draft = github.Ptr("PR_node1"), github.Ptr(false)
+		return pr
+	}
+	refused := map[string]any{"errors": []map[string]string{{"type": "FORBIDDEN", "message": "Resource not accessible by integration"}}}
+
+	// editPR answers the edit of PR #1 of 'api' like GitHub, applying the fields that were sent
+	editPR := func(t *testing.T, pr *github.PullRequest) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// GitHub takes the base as a branch name
+			var edit struct {
+				Title *string `json:"title"`
+				State *string `json:"state"`
+				Base  *string `json:"base"`
+			}
+			readJSON(t, r, &edit)
+
+			if edit.Title != nil {
+				pr.Title = edit.Title
+			}
+			if edit.State != nil {
+				pr.State = edit.State
+			}
+			if edit.Base != nil {
+				pr.Base.Ref = edit.Base
+			}
+			writeJSON(w, http.StatusOK, pr)
+		}
+	}
+
+	t.Run("Update title, base and mark ready for review", func(t *testing.T) {
+		pr := newPR()
+		pr.Draft = github.Ptr(true)
+
+		fake, app := newFakeGitHub(t)
+		fake.handle("PATCH /repos/owner/api/pulls/1", editPR(t, pr))
+		fake.handle("POST /graphql", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Query string `json:"query"`
+			}
+			readJSON(t, r, &req)
+			assert.Contains(t, req.Query, "markPullRequestReadyForReview", "PR should be marked ready for review")
+
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{}})
+		})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"title": "New title", "base": "develop", "draft": false}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "New title", response.Title, "Title should be updated")
+		assert.Equal(t, "develop", response.Base, "Base should be updated")
+		assert.False(t, response.Draft, "PR should be ready for review")
+		assert.Equal(t, "open", response.State, "State should not change")
+	})
+
+	t.Run("Close and reopen", func(t *testing.T) {
+		pr := newPR()
+
+		fake, app := newFakeGitHub(t)
+		fake.handle("PATCH /repos/owner/api/pulls/1", editPR(t, pr))
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"state": "closed"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "closed", pr.GetState(), "PR should be closed")
+
+		w = serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"state": "open"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "open", pr.GetState(), "PR should be reopened")
+	})
+
+	t.Run("Reopen a merged pull request", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("PATCH /repos/owner/api/pulls/2", http.StatusUnprocessableEntity, map[string]any{
+			"message": "Validation Failed",
+			"errors":  []map[string]string{{"resource": "PullRequest", "code": "custom", "message": "state cannot be changed. The pull request cannot be reopened."}},
+		})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/2", `{"state": "open"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Invalid updates", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"state": "merged"}`, `{"title": ""}`} {
+			// Nothing is registered, invalid updates must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for %s", body)
+		}
+	})
+
+	t.Run("Pull request does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("PATCH /repos/owner/api/pulls/9", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/9", `{"title": "New title"}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+
+	t.Run("Convert to draft through the GraphQL API of GitHub Enterprise", func(t *testing.T) {
+		fake, app := newFakeGitHubAt(t, "/api/v3")
+		fake.reply("GET /api/v3/repos/owner/api/pulls/1", http.StatusOK, newPR())
+		fake.handle("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+			readJSON(t, r, &req)
+			assert.Contains(t, req.Query, "convertPullRequestToDraft", "PR should be converted to a draft")
+			assert.Equal(t, "PR_node1", req.Variables["id"], "Mutation should target the PR node")
+
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{}})
+		})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"draft": true}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.True(t, response.Draft, "PR should be a draft")
+		assert.Empty(t, response.Warnings, "Nothing should have failed")
+	})
+
+	t.Run("Draft only change that fails is an error", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls/1", http.StatusOK, newPR())
+		fake.reply("POST /graphql", http.StatusOK, refused)
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"draft": true}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "Code should be 403 Forbidden")
+		assert.Contains(t, w.Body.String(), "Resource not accessible by integration", "GraphQL error should be reported")
+	})
+
+	t.Run("Draft change that fails after an edit is a warning", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("PATCH /repos/owner/api/pulls/1", http.StatusOK, newPR())
+		fake.reply("POST /graphql", http.StatusOK, refused)
+
+		w := serveJSON(t, app, "PATCH", "/repositories/api/pull-requests/1", `{"title": "New title", "draft": true}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK since the title was saved")
+
+		var response models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.False(t, response.Draft, "PR should not be a draft")
+		assert.Len(t, response.Warnings, 1, "Draft failure should be reported as a warning")
+	})
+}
+
+func TestClosePullRequests(t *testing.T) {
+	prs := []*github.PullRequest{
+		testPullRequest(1, "open", "dependabot", "deps-a", time.Now()),
+		testPullRequest(2, "open", "alice", "feature", time.Now()),
+		testPullRequest(3, "open", "dependabot", "deps-b", time.Now()),
+		testPullRequest(4, "closed", "dependabot", "deps-c", time.Now()),
+	}
+
+	// newFakeClient serves the pull requests of 'test-repo', comments holds the comment posted on each one
+	newFakeClient := func(t *testing.T) (*fakeGitHub, *handlers.Application, map[string]string) {
+		comments := make(map[string]string)
+
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /repos/owner/test-repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+			open := []*github.PullRequest{}
+			for _, pr := range prs {
+				if pr.GetState() == r.URL.Query().Get("state") {
+					open = append(open, pr)
+				}
+			}
+			writeJSON(w, http.StatusOK, open)
+		})
+		fake.handle("POST /repos/owner/test-repo/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+			var comment github.IssueComment
+			readJSON(t, r, &comment)
+			comments[r.PathValue("number")] = comment.GetBody()
+
+			writeJSON(w, http.StatusCreated, comment)
+		})
+		fake.handle("PATCH /repos/owner/test-repo/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+			var edit github.PullRequest
+			readJSON(t, r, &edit)
+			assert.Equal(t, "closed", edit.GetState(), "PR should be closed")
+			assert.Contains(t, comments, r.PathValue("number"), "PR should be commented on before being closed")
+
+			writeJSON(w, http.StatusOK, edit)
+		})
+		return fake, app, comments
+	}
+
+	closePRs := func(t *testing.T, app *handlers.Application, query, body string) (*httptest.ResponseRecorder, models.BulkCloseResponse) {
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/pull-requests/close?"+query, body)
+
+		var response models.BulkCloseResponse
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	t.Run("Close the matching pull requests with a comment", func(t *testing.T) {
+		fake, app, comments := newFakeClient(t)
+
+		w, response := closePRs(t, app, "author=dependabot", `{"comment": "Superseded"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, 2, response.Matched, "Only open PRs by the author should match")
+		assert.Equal(t, []int{1, 3}, response.Closed, "PRs #1 and #3 should be closed")
+		assert.True(t, fake.called("PATCH /repos/owner/test-repo/pulls/1"), "PR #1 should be closed")
+		assert.False(t, fake.called("PATCH /repos/owner/test-repo/pulls/2"), "PR #2 should stay open")
+		assert.Equal(t, "Superseded", comments["1"], "Comment should be posted on PR #1")
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		fake, app, comments := newFakeClient(t)
+
+		w, response := closePRs(t, app, "author=dependabot", `{"comment": "Superseded", "dry_run": true}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, response.DryRun, "Response should be flagged as a dry run")
+		assert.Equal(t, []int{1, 3}, response.Closed, "PRs #1 and #3 would be closed")
+		assert.False(t, fake.called("PATCH /repos/owner/test-repo/pulls/1"), "PR #1 should stay open")
+		assert.Empty(t, comments, "No comment should be posted")
+	})
+
+	t.Run("Failures are reported per pull request", func(t *testing.T) {
+		fake, app, _ := newFakeClient(t)
+		fake.reply("POST /repos/owner/test-repo/issues/3/comments", http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+
+		w, response := closePRs(t, app, "author=dependabot", `{"comment": "Superseded"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{1}, response.Closed, "PR #1 should be closed")
+		assert.Len(t, response.Failed, 1, "PR #3 should have failed")
+		assert.Equal(t, 3, response.Failed[0].Number, "Failure should reference PR #3")
+		assert.Equal(t, models.ErrCodeForbidden, response.Failed[0].Code, "Error code should be 'forbidden'")
+		assert.False(t, fake.called("PATCH /repos/owner/test-repo/pulls/3"), "PR #3 should stay open without its comment")
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for _, tc := range []struct{ query, body string }{
+			{"", `{"comment": "Superseded"}`},
+			{"state=closed&author=dependabot", `{"comment": "Superseded"}`},
+			{"author=dependabot", `{}`},
+		} {
+			// Nothing is registered, invalid requests must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w, _ := closePRs(t, app, tc.query, tc.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for '%s' %s", tc.query, tc.body)
+		}
+	})
+}
+
+// numbersOf returns the number of every pull request
+func numbersOf(prs []models.PullRequestResponse) []int {
+	var numbers []int
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	return numbers
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseOptionalBool reads a boolean query parameter, nil means the parameter was not given
+func parseOptionalBool(c *gin.Context, name string) (*bool, error) {
+	value, ok := c.GetQuery(name)
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s parameter", name)
+	}
+	return &parsed, nil
+}
@@ -3,52 +3,98 @@ package handlers
 import (
 	"context"
 	"errors"
-	_"fmt"
+	"fmt"
+	"math"
 	"net/http"
 	"os"
-	"strconv"
+	"sort"
+	"sync"
+	"time"
 
 	"github-api-service/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v68/github"
-	_"github.com/joho/godotenv"
+	_ "github.com/joho/godotenv"
 	"golang.org/x/oauth2"
 )
 
 // Use an interface for ease of testing with mocking
 type ApplicationInterface interface {
-    CreateRepository(c *gin.Context)
-    DeleteRepository(c *gin.Context)
-    ListRepositories(c *gin.Context)
-    ListOpenPullRequests(c *gin.Context)
+	CreateRepository(c *gin.Context)
+	CreateRepositoryFromTemplate(c *gin.Context)
+	DeleteRepository(c *gin.Context)
+	ArchiveRepository(c *gin.Context)
+	UnarchiveRepository(c *gin.Context)
+	UpdateRepository(c *gin.Context)
+	ListRepositories(c *gin.Context)
+	GetRepository(c *gin.Context)
+	ListPullRequests(c *gin.Context)
+	CreatePullRequest(c *gin.Context)
+	GetPullRequest(c *gin.Context)
+	GetPullRequestDiff(c *gin.Context)
+	GetPullRequestPatch(c *gin.Context)
+	UpdatePullRequest(c *gin.Context)
+	ClosePullRequests(c *gin.Context)
+	MergePullRequest(c *gin.Context)
+	ListAccountPullRequests(c *gin.Context)
+	ListStalePullRequests(c *gin.Context)
+	GetPullRequestMetrics(c *gin.Context)
+	ListAccountStalePullRequests(c *gin.Context)
+	CreateReview(c *gin.Context)
+	ListReviews(c *gin.Context)
+	ListReviewComments(c *gin.Context)
+	DismissReview(c *gin.Context)
+	RequestReviewers(c *gin.Context)
+	RemoveReviewers(c *gin.Context)
+	ListBranches(c *gin.Context)
+	GetBranch(c *gin.Context)
+	CreateBranch(c *gin.Context)
+	RenameBranch(c *gin.Context)
+	DeleteBranch(c *gin.Context)
+	PruneBranches(c *gin.Context)
+	GetBranchProtection(c *gin.Context)
+	UpdateBranchProtection(c *gin.Context)
+	GetContents(c *gin.Context)
+	UpdateFile(c *gin.Context)
+	DeleteFile(c *gin.Context)
+	CreateCommit(c *gin.Context)
+	GetCompliance(c *gin.Context)
+	RemediateCompliance(c *gin.Context)
+	ListBackups(c *gin.Context)
+	RestoreBackup(c *gin.Context)
 }
 
 // Github service wrapper
 type Application struct {
-    githubClient *github.Client
-    owner string
+	githubClient *github.Client
+	owner        string
+	maxResults   int
+	deleteGuard  *deleteGuard
+	backups      *backupStore
+	metricsCache *ttlCache[models.PullRequestMetrics]
+	compliance   *compliancePolicy
 }
 
 // ApplicationInterface wrapper for dependency injection
 type Client struct {
-    App ApplicationInterface
+	App ApplicationInterface
 }
 
 // GetClientForTest returns a mock client to facilitate testing
 func GetClientForTest(mockClient ApplicationInterface) *Client {
-    return &Client{ App: mockClient }
+	return &Client{App: mockClient}
 }
 
 // GetClient initializes a GitHub client using OAuth authentication
 func GetClient() (*Client, error) {
-    // Use this if running without minikube
+	// Use this if running without minikube
 	// err := godotenv.Load("config.env")
 	// if err != nil {
 	// 	fmt.Println("Warning: Could not load .env file. Using system environment variables.")
 	// }
-	
-    // Load authentication details
+
+	// Load authentication details
 	token := os.Getenv("TOKEN")
 	owner := os.Getenv("OWNER")
 
@@ -59,138 +105,545 @@ func GetClient() (*Client, error) {
 		return nil, errors.New("missing owner")
 	}
 
-    // Create a client with the access token
+	// Hard cap on the number of items returned when walking every page
+	maxResults, err := maxResultsFromEnv(os.Getenv("MAX_RESULTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Repositories matching PROTECTED_REPOS can never be deleted
+	guard, err := newDeleteGuard(os.Getenv("DELETE_TOKEN_SECRET"), parseProtectedRepos(os.Getenv("PROTECTED_REPOS")))
+	if err != nil {
+		return nil, err
+	}
+
+	// Repositories are backed up here before being deleted
+	backupDir := os.Getenv("BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = defaultBackupDir
+	}
+	backups, err := newBackupStore(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Computed pull request metrics are served from memory for METRICS_CACHE_TTL
+	metricsCacheTTL, err := metricsCacheTTLFromEnv(os.Getenv("METRICS_CACHE_TTL"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Repositories are evaluated against the rules of the COMPLIANCE_POLICY file, compliance is off without one
+	compliance, err := loadCompliancePolicy(os.Getenv("COMPLIANCE_POLICY"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a client with the access token
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	tc := oauth2.NewClient(ctx, ts)
 
 	application := &Application{
-        githubClient: github.NewClient(tc),
-        owner: owner,
-    }
+		githubClient: github.NewClient(tc),
+		owner:        owner,
+		maxResults:   maxResults,
+		deleteGuard:  guard,
+		backups:      backups,
+		metricsCache: newTTLCache[models.PullRequestMetrics](metricsCacheTTL),
+		compliance:   compliance,
+	}
 
-	return &Client{ App: application }, nil
+	return &Client{App: application}, nil
 }
 
 // CreateRepository handles the creation of a new GitHub repository
+// Topics and a custom default branch are applied once the repository exists
 func (a *Application) CreateRepository(c *gin.Context) {
-    var req models.RepoRequest
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    
-    // Construct a GitHub repository object from the request
-    repo := &github.Repository{
-        Name:        github.Ptr(req.Name),
-        Description: github.Ptr(req.Description),
-        Private:     github.Ptr(req.Private),
-    }
-    
-    ctx := context.Background()
-    newRepo, _, err := a.githubClient.Repositories.Create(ctx, "", repo)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-
-    // Return the created repository details
-	response := models.RepoResponse{
-        Message: "Repository created successfully",
-        Name: newRepo.GetName(),
-        Description: newRepo.GetDescription(),
-        Private: newRepo.GetPrivate(),
-    }
-    
-    c.JSON(http.StatusCreated, response)
+	var req models.RepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	if err := validateRepoRequest(req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	// Construct a GitHub repository object from the request
+	repo := repoFromRequest(req)
+
+	ctx := context.Background()
+	newRepo, _, err := a.githubClient.Repositories.Create(ctx, req.Organization, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// The repository exists at this point, so follow-up failures are reported as warnings
+	var warnings []string
+	owner := newRepo.GetOwner().GetLogin()
+
+	if len(req.Topics) > 0 {
+		topics, _, err := a.githubClient.Repositories.ReplaceAllTopics(ctx, owner, newRepo.GetName(), req.Topics)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to set topics: %v", err))
+		} else {
+			newRepo.Topics = topics
+		}
+	}
+
+	if req.DefaultBranch != "" && req.DefaultBranch != newRepo.GetDefaultBranch() {
+		// Renaming the initial branch also makes it the default branch
+		_, _, err := a.githubClient.Repositories.RenameBranch(ctx, owner, newRepo.GetName(), newRepo.GetDefaultBranch(), req.DefaultBranch)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to set default branch: %v", err))
+		} else {
+			newRepo.DefaultBranch = github.Ptr(req.DefaultBranch)
+		}
+	}
+
+	// Return the created repository details
+	response := newRepoResponse("Repository created successfully", newRepo)
+	response.Warnings = warnings
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// validateRepoRequest checks the option combinations GitHub would reject
+func validateRepoRequest(req models.RepoRequest) error {
+	if req.Visibility == "internal" && req.Organization == "" {
+		return errors.New("internal visibility is only available for organization repositories")
+	}
+	if req.DefaultBranch != "" && !req.AutoInit {
+		return errors.New("default_branch requires auto_init")
+	}
+	return nil
+}
+
+// repoFromRequest converts a creation request into the GitHub create payload
+func repoFromRequest(req models.RepoRequest) *github.Repository {
+	repo := &github.Repository{
+		Name:                github.Ptr(req.Name),
+		Description:         github.Ptr(req.Description),
+		Private:             github.Ptr(req.Private),
+		HasIssues:           req.HasIssues,
+		HasWiki:             req.HasWiki,
+		HasProjects:         req.HasProjects,
+		AllowSquashMerge:    req.AllowSquashMerge,
+		AllowRebaseMerge:    req.AllowRebaseMerge,
+		AllowMergeCommit:    req.AllowMergeCommit,
+		DeleteBranchOnMerge: req.DeleteBranchOnMerge,
+	}
+
+	// Visibility takes precedence over the private flag
+	if req.Visibility != "" {
+		repo.Private = nil
+		repo.Visibility = github.Ptr(req.Visibility)
+	}
+	if req.Homepage != "" {
+		repo.Homepage = github.Ptr(req.Homepage)
+	}
+	if req.AutoInit {
+		repo.AutoInit = github.Ptr(true)
+	}
+	if req.GitignoreTemplate != "" {
+		repo.GitignoreTemplate = github.Ptr(req.GitignoreTemplate)
+	}
+	if req.LicenseTemplate != "" {
+		repo.LicenseTemplate = github.Ptr(req.LicenseTemplate)
+	}
+
+	return repo
+}
+
+// CreateRepositoryFromTemplate generates a new repository from a template repository
+func (a *Application) CreateRepositoryFromTemplate(c *gin.Context) {
+	var req models.TemplateRepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	owner := req.Owner
+	if owner == "" {
+		owner = a.owner
+	}
+
+	templateReq := &github.TemplateRepoRequest{
+		Name:               github.Ptr(req.Name),
+		Owner:              github.Ptr(owner),
+		Description:        github.Ptr(req.Description),
+		Private:            github.Ptr(req.Private),
+		IncludeAllBranches: github.Ptr(req.IncludeAllBranches),
+	}
+
+	ctx := context.Background()
+	newRepo, _, err := a.githubClient.Repositories.CreateFromTemplate(ctx, req.TemplateOwner, req.TemplateRepo, templateReq)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	response := newRepoResponse("Repository created from template successfully", newRepo)
+	response.Template = req.TemplateOwner + "/" + req.TemplateRepo
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// UpdateRepository applies a partial update to an existing repository
+func (a *Application) UpdateRepository(c *gin.Context) {
+	repo := c.Param("repo")
+
+	var req models.RepoUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	if req.IsEmpty() {
+		respondWithBadRequest(c, "No fields to update")
+		return
+	}
+
+	ctx := context.Background()
+	updatedRepo, _, err := a.githubClient.Repositories.Edit(ctx, a.owner, repo, repoFromUpdate(req))
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	response := newRepoResponse("Repository updated successfully", updatedRepo)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// repoFromUpdate converts a partial update into the GitHub edit payload
+// Nil fields are omitted so GitHub leaves them untouched
+func repoFromUpdate(req models.RepoUpdateRequest) *github.Repository {
+	return &github.Repository{
+		Name:                req.Name,
+		Description:         req.Description,
+		Homepage:            req.Homepage,
+		Visibility:          req.Visibility,
+		DefaultBranch:       req.DefaultBranch,
+		AllowSquashMerge:    req.AllowSquashMerge,
+		AllowRebaseMerge:    req.AllowRebaseMerge,
+		AllowMergeCommit:    req.AllowMergeCommit,
+		DeleteBranchOnMerge: req.DeleteBranchOnMerge,
+		HasIssues:           req.HasIssues,
+		HasWiki:             req.HasWiki,
+		HasProjects:         req.HasProjects,
+	}
+}
+
+// newRepoResponse converts a GitHub repository into the settings echoed back to clients
+func newRepoResponse(message string, repo *github.Repository) models.RepoResponse {
+	return models.RepoResponse{
+		Message:             message,
+		Name:                repo.GetName(),
+		Owner:               repo.GetOwner().GetLogin(),
+		Description:         repo.GetDescription(),
+		Private:             repo.GetPrivate(),
+		Homepage:            repo.GetHomepage(),
+		Visibility:          repo.GetVisibility(),
+		DefaultBranch:       repo.GetDefaultBranch(),
+		License:             repo.GetLicense().GetKey(),
+		Topics:              repo.Topics,
+		HtmlURL:             repo.GetHTMLURL(),
+		AllowSquashMerge:    repo.GetAllowSquashMerge(),
+		AllowRebaseMerge:    repo.GetAllowRebaseMerge(),
+		AllowMergeCommit:    repo.GetAllowMergeCommit(),
+		DeleteBranchOnMerge: repo.GetDeleteBranchOnMerge(),
+		Archived:            repo.GetArchived(),
+		HasIssues:           repo.GetHasIssues(),
+		HasWiki:             repo.GetHasWiki(),
+		HasProjects:         repo.GetHasProjects(),
+	}
 }
 
 // ListRepositories retrieves all repositories owned by the authenticated user
+// Every page is fetched up to the configured cap unless the client asks for a specific page
+// Type, visibility, sort and direction are applied by GitHub, name, topic, language and archived
+// status are filtered in-process so a page may hold fewer repositories than per_page
 func (a *Application) ListRepositories(c *gin.Context) {
-    ctx := context.Background()
-    opts := &github.RepositoryListByAuthenticatedUserOptions{
-        ListOptions: github.ListOptions{PerPage: 100},
-		Type: "owner",
-    }
-    
-    repos, _, err := a.githubClient.Repositories.ListByAuthenticatedUser(ctx, opts)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    
-    // Convert the GitHub response into a simplified format
-    formattedRepos := make([]models.RepoRequest, 0, len(repos))
-    for _, repo := range repos {
-        formattedRepos = append(formattedRepos, models.RepoRequest{
-            Name:        repo.GetName(),
-            Description: repo.GetDescription(),
-            Private:     repo.GetPrivate(),
-        })
-    }
-    
-    c.JSON(http.StatusOK, formattedRepos)
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	filter, err := parseRepoFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	repos, err := fetchPages(c, p, a.maxResults, func(listOpts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return a.githubClient.Repositories.ListByAuthenticatedUser(ctx, filter.listOptions(listOpts))
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Convert the GitHub response into a simplified format
+	formattedRepos := make([]models.RepoSummary, 0, len(repos))
+	for _, repo := range filter.apply(repos) {
+		formattedRepos = append(formattedRepos, newRepoSummary(repo))
+	}
+
+	c.JSON(http.StatusOK, formattedRepos)
+}
+
+// newRepoSummary converts a GitHub repository into its listing format
+func newRepoSummary(repo *github.Repository) models.RepoSummary {
+	return models.RepoSummary{
+		Name:        repo.GetName(),
+		Description: repo.GetDescription(),
+		Private:     repo.GetPrivate(),
+		Archived:    repo.GetArchived(),
+		Visibility:  repo.GetVisibility(),
+		Language:    repo.GetLanguage(),
+		Topics:      repo.Topics,
+		CreatedAt:   repo.GetCreatedAt().Time,
+		UpdatedAt:   repo.GetUpdatedAt().Time,
+		PushedAt:    repo.GetPushedAt().Time,
+	}
+}
+
+// GetRepository returns the details of a single repository
+// The repository, its languages and its topics are fetched concurrently
+func (a *Application) GetRepository(c *gin.Context) {
+	repo := c.Param("repo")
+	ctx := context.Background()
+
+	var (
+		wg           sync.WaitGroup
+		ghRepo       *github.Repository
+		languages    map[string]int
+		topics       []string
+		repoErr      error
+		languagesErr error
+		topicsErr    error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		ghRepo, _, repoErr = a.githubClient.Repositories.Get(ctx, a.owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		languages, _, languagesErr = a.githubClient.Repositories.ListLanguages(ctx, a.owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		topics, _, topicsErr = a.githubClient.Repositories.ListAllTopics(ctx, a.owner, repo)
+	}()
+	wg.Wait()
+
+	for _, err := range []error{repoErr, languagesErr, topicsErr} {
+		if err != nil {
+			respondWithGitHubError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newRepoDetail(ghRepo, languages, topics))
+}
+
+// newRepoDetail combines a repository with its languages and topics
+func newRepoDetail(repo *github.Repository, languages map[string]int, topics []string) models.RepoDetail {
+	if topics == nil {
+		topics = []string{}
+	}
+
+	return models.RepoDetail{
+		Name:          repo.GetName(),
+		FullName:      repo.GetFullName(),
+		Owner:         repo.GetOwner().GetLogin(),
+		Description:   repo.GetDescription(),
+		Visibility:    repo.GetVisibility(),
+		Private:       repo.GetPrivate(),
+		Archived:      repo.GetArchived(),
+		DefaultBranch: repo.GetDefaultBranch(),
+		Languages:     languageShares(languages),
+		Topics:        topics,
+		Stars:         repo.GetStargazersCount(),
+		Forks:         repo.GetForksCount(),
+		OpenIssues:    repo.GetOpenIssuesCount(),
+		Size:          repo.GetSize(),
+		License:       repo.GetLicense().GetKey(),
+		CreatedAt:     repo.GetCreatedAt().Time,
+		PushedAt:      repo.GetPushedAt().Time,
+		HtmlURL:       repo.GetHTMLURL(),
+		CloneURLs: models.CloneURLs{
+			HTTPS: repo.GetCloneURL(),
+			SSH:   repo.GetSSHURL(),
+			Git:   repo.GetGitURL(),
+		},
+		Permissions: repo.Permissions,
+	}
+}
+
+// languageShares converts the bytes per language into a breakdown sorted by size
+func languageShares(languages map[string]int) []models.LanguageShare {
+	total := 0
+	for _, bytes := range languages {
+		total += bytes
+	}
+
+	shares := make([]models.LanguageShare, 0, len(languages))
+	for name, bytes := range languages {
+		share := models.LanguageShare{Name: name, Bytes: bytes}
+		if total > 0 {
+			// Rounded to two decimals
+			share.Percent = math.Round(float64(bytes)*10000/float64(total)) / 100
+		}
+		shares = append(shares, share)
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Bytes != shares[j].Bytes {
+			return shares[i].Bytes > shares[j].Bytes
+		}
+		return shares[i].Name < shares[j].Name
+	})
+	return shares
 }
 
 // DeleteRepository removes a repository from the authenticated user's GitHub
+// Deletion takes two calls: the first returns a confirmation token bound to the repository's
+// current state and the second deletes it when the token is sent back as '?confirm=<token>'
+// A backup is taken before deleting and the repository is kept if the backup fails
+// With '?mode=archive' the repository is archived instead of deleted
 func (a *Application) DeleteRepository(c *gin.Context) {
-    repo := c.Param("repo")
-    
-    ctx := context.Background()
-    _, err := a.githubClient.Repositories.Delete(ctx, a.owner, repo)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    
-    // Return success message after deletion
+	repo := c.Param("repo")
+
+	switch c.DefaultQuery("mode", "delete") {
+	case "delete":
+	case "archive":
+		a.setArchived(c, repo, true)
+		return
+	default:
+		respondWithBadRequest(c, "Invalid mode parameter, must be 'delete' or 'archive'")
+		return
+	}
+
+	if a.deleteGuard.isProtected(repo) {
+		respondWithError(c, http.StatusForbidden, models.ErrCodeForbidden, fmt.Sprintf("Repository '%s' is protected and cannot be deleted", repo))
+		return
+	}
+
+	ctx := context.Background()
+	meta, err := a.deleteMetadata(ctx, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	token := c.Query("confirm")
+	if token == "" {
+		c.JSON(http.StatusAccepted, newDeleteConfirmation(a.deleteGuard, repo, meta))
+		return
+	}
+	if err := a.deleteGuard.verifyToken(token, repo, meta, time.Now()); err != nil {
+		respondWithError(c, http.StatusConflict, models.ErrCodeConflict, err.Error())
+		return
+	}
+
+	backup, err := a.backupRepository(ctx, repo)
+	var storageErr *storageError
+	if errors.As(err, &storageErr) {
+		respondWithError(c, http.StatusInternalServerError, models.ErrCodeInternal, fmt.Sprintf("backup failed, repository was not deleted: %v", err))
+		return
+	}
+	if err != nil {
+		respondWithGitHubError(c, fmt.Errorf("backup failed, repository was not deleted: %w", err))
+		return
+	}
+
+	_, err = a.githubClient.Repositories.Delete(ctx, a.owner, repo)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// Return success message after deletion
 	response := models.DeleteRepoResponse{
-        Message: "Repository deleted successfully",
-    }
-    
-    c.JSON(http.StatusOK, response)
-}
-
-// ListOpenPullRequests fetches open PRs for a given repository
-func (a *Application) ListOpenPullRequests(c *gin.Context) {
-    repo := c.Param("repo") // Get repository name from URL parameter
-
-    ctx := context.Background()
-    opts := &github.PullRequestListOptions{
-        State: "open", // Fetch only open pull requests
-        ListOptions: github.ListOptions{PerPage: 100},
-    }
-
-    pullRequests, _, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, opts)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-
-    // Get the 'limit' query parameter if provided
-    limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
-    if err != nil || limit < 0 {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
-        return
-    }
-
-    // Apply limit if it's greater than 0 and less than the total number of PRs
-    if limit > 0 && limit < len(pullRequests) {
-        pullRequests = pullRequests[:limit]
-    }
-
-    // Convert PR data into a simplified format
-    formattedPRs := make([]models.PullRequestResponse, 0, len(pullRequests))
-    for _, pr := range pullRequests {
-        formattedPRs = append(formattedPRs, models.PullRequestResponse{
-            Title:       pr.GetTitle(),
-            Number:      pr.GetNumber(),
-            User:        pr.GetUser().GetLogin(),
-            CreatedAt:   pr.GetCreatedAt().Time,
-            HtmlURL:     pr.GetHTMLURL(),
-        })
-    }
-
-    c.JSON(http.StatusOK, formattedPRs)
+		Message:  "Repository deleted successfully",
+		BackupID: backup.ID,
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
+// deleteMetadata fetches the repository state a delete confirmation is bound to
+func (a *Application) deleteMetadata(ctx context.Context, repo string) (models.RepoDeleteMetadata, error) {
+	ghRepo, _, err := a.githubClient.Repositories.Get(ctx, a.owner, repo)
+	if err != nil {
+		return models.RepoDeleteMetadata{}, err
+	}
+
+	// With one PR per page the last page number is the number of open PRs
+	opts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 1}}
+	pullRequests, resp, err := a.githubClient.PullRequests.List(ctx, a.owner, repo, opts)
+	if err != nil {
+		return models.RepoDeleteMetadata{}, err
+	}
+	openPRs := len(pullRequests)
+	if resp.LastPage > 0 {
+		openPRs = resp.LastPage
+	}
+
+	return models.RepoDeleteMetadata{
+		Stars:            ghRepo.GetStargazersCount(),
+		PushedAt:         ghRepo.GetPushedAt().Time,
+		OpenPullRequests: openPRs,
+	}, nil
+}
+
+// newDeleteConfirmation issues the token returned by the first delete call
+func newDeleteConfirmation(guard *deleteGuard, repo string, meta models.RepoDeleteMetadata) models.DeleteConfirmationResponse {
+	token, expiresAt := guard.issueToken(repo, meta, time.Now())
+	return models.DeleteConfirmationResponse{
+		Message:    "Send the token back as '?confirm=<token>' to delete the repository",
+		Repository: repo,
+		Token:      token,
+		ExpiresAt:  expiresAt,
+		Metadata:   meta,
+	}
+}
+
+// ArchiveRepository makes a repository read-only, it can be restored with UnarchiveRepository
+func (a *Application) ArchiveRepository(c *gin.Context) {
+	a.setArchived(c, c.Param("repo"), true)
+}
+
+// UnarchiveRepository makes an archived repository writable again
+func (a *Application) UnarchiveRepository(c *gin.Context) {
+	a.setArchived(c, c.Param("repo"), false)
+}
+
+// setArchived toggles the archived flag of a repository and writes the resulting settings
+func (a *Application) setArchived(c *gin.Context, repo string, archived bool) {
+	ctx := context.Background()
+	updatedRepo, _, err := a.githubClient.Repositories.Edit(ctx, a.owner, repo, &github.Repository{
+		Archived: github.Ptr(archived),
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newRepoResponse(archiveMessage(archived), updatedRepo))
+}
+
+// archiveMessage returns the success message for an archive or unarchive
+func archiveMessage(archived bool) string {
+	if archived {
+		return "Repository archived successfully"
+	}
+	return "Repository unarchived successfully"
+}
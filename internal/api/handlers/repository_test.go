@@ -4,14 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
-	"github-api-service/internal/models"
 	"github-api-service/internal/api/handlers"
 	"github-api-service/internal/api/routes"
+	"github-api-service/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v68/github"
@@ -20,11 +21,10 @@ import (
 
 var (
 	errJSONMarshal   = errors.New("failed to marshal repository request")
-    errJSONUnmarshal = errors.New("failed to unmarshal repository response")
-    errRequestCreate = errors.New("failed to create HTTP request")
+	errJSONUnmarshal = errors.New("failed to unmarshal repository response")
+	errRequestCreate = errors.New("failed to create HTTP request")
 )
 
-
 func TestCreateRepository(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -38,9 +38,9 @@ func TestCreateRepository(t *testing.T) {
 
 	t.Run("Successful repository creation", func(t *testing.T) {
 		request := models.RepoRequest{
-			Name: "hello-world",
+			Name:        "hello-world",
 			Description: "test repository",
-			Private: false,
+			Private:     false,
 		}
 
 		requestBody, err := json.Marshal(request)
@@ -58,19 +58,18 @@ func TestCreateRepository(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, w.Code, `Code should be 201 Created`)
 		assert.Equal(t, request.Name, response.Name, "Repository name should match")
-    	assert.Equal(t, request.Description, response.Description, "Repository description should match")
-    	assert.Equal(t, request.Private, response.Private, "Repository private status should match")
+		assert.Equal(t, request.Description, response.Description, "Repository description should match")
+		assert.Equal(t, request.Private, response.Private, "Repository private status should match")
 	})
 
-	
 	t.Run("Invalid JSON payload", func(t *testing.T) {
 		request := `{name: "invalid"}` // Invalid JSON
 		req, _ := http.NewRequest("POST", "/repositories", bytes.NewBufferString(request))
 		req.Header.Set("Content-Type", "application/json")
-		
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-		
+
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
 	})
 
@@ -78,10 +77,10 @@ func TestCreateRepository(t *testing.T) {
 		request := `{"description": "test repo"}`
 		req, _ := http.NewRequest("POST", "/repositories", bytes.NewBufferString(request))
 		req.Header.Set("Content-Type", "application/json")
-		
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-		
+
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
 	})
 
@@ -90,11 +89,11 @@ func TestCreateRepository(t *testing.T) {
 		mockClientError := &handlers.GitHubMock{MockError: errors.New("github api error")}
 		ghClient := handlers.GetClientForTest(mockClientError)
 		routes.SetupRoutes(r, *ghClient)
-		
+
 		request := models.RepoRequest{
-			Name: "hello-world",
+			Name:        "hello-world",
 			Description: "test repository",
-			Private: false,
+			Private:     false,
 		}
 
 		requestBody, err := json.Marshal(request)
@@ -109,7 +108,213 @@ func TestCreateRepository(t *testing.T) {
 		var response models.RepoResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+	})
+}
+
+func TestCreateRepositoryOptions(t *testing.T) {
+	request := `{
+		"name": "service",
+		"description": "new service",
+		"organization": "my-org",
+		"visibility": "internal",
+		"auto_init": true,
+		"gitignore_template": "Go",
+		"license_template": "mit",
+		"default_branch": "trunk",
+		"topics": ["go", "api"],
+		"has_wiki": false,
+		"allow_squash_merge": true
+	}`
+
+	created := github.Repository{
+		Name:             github.Ptr("service"),
+		Owner:            &github.User{Login: github.Ptr("my-org")},
+		Description:      github.Ptr("new service"),
+		Private:          github.Ptr(true),
+		Visibility:       github.Ptr("internal"),
+		DefaultBranch:    github.Ptr("main"),
+		License:          &github.License{Key: github.Ptr("mit")},
+		HasWiki:          github.Ptr(false),
+		AllowSquashMerge: github.Ptr(true),
+	}
+
+	t.Run("Create organization repository with options", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /orgs/my-org/repos", func(w http.ResponseWriter, r *http.Request) {
+			var repo map[string]any
+			readJSON(t, r, &repo)
+			assert.Equal(t, "internal", repo["visibility"], "Visibility should be sent")
+			assert.NotContains(t, repo, "private", "Visibility should take precedence over the private flag")
+			assert.Equal(t, true, repo["auto_init"], "Auto init should be sent")
+			assert.Equal(t, "Go", repo["gitignore_template"], "Gitignore template should be sent")
+			assert.Equal(t, "mit", repo["license_template"], "License template should be sent")
+			assert.Equal(t, false, repo["has_wiki"], "Wiki setting should be sent")
+
+			writeJSON(w, http.StatusCreated, created)
+		})
+		fake.handle("PUT /repos/my-org/service/topics", func(w http.ResponseWriter, r *http.Request) {
+			var topics map[string][]string
+			readJSON(t, r, &topics)
+			writeJSON(w, http.StatusOK, topics)
+		})
+		fake.handle("POST /repos/my-org/service/branches/main/rename", func(w http.ResponseWriter, r *http.Request) {
+			var rename map[string]string
+			readJSON(t, r, &rename)
+			assert.Equal(t, "trunk", rename["new_name"], "Initial branch should be renamed to 'trunk'")
+			writeJSON(w, http.StatusCreated, github.Branch{Name: github.Ptr("trunk")})
+		})
+
+		w := serveJSON(t, app, "POST", "/repositories", request)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "my-org", response.Owner, "Repository should be owned by the organization")
+		assert.Equal(t, "internal", response.Visibility, "Visibility should be internal")
+		assert.True(t, response.Private, "Internal repositories are not public")
+		assert.Equal(t, "trunk", response.DefaultBranch, "Default branch should be 'trunk'")
+		assert.Equal(t, "mit", response.License, "License should be 'mit'")
+		assert.Equal(t, []string{"go", "api"}, response.Topics, "Topics should match")
+		assert.True(t, response.AllowSquashMerge, "Squash merge should be allowed")
+		assert.False(t, response.HasWiki, "Wiki should be disabled")
+		assert.Empty(t, response.Warnings, "Nothing should have failed")
+	})
+
+	t.Run("Create user repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("POST /user/repos", http.StatusCreated, github.Repository{Name: github.Ptr("service"), Owner: &github.User{Login: github.Ptr("owner")}})
+
+		w := serveJSON(t, app, "POST", "/repositories", `{"name": "service"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+		assert.True(t, fake.called("POST /user/repos"), "Repository should be created for the authenticated user")
+	})
+
+	t.Run("Follow-up failures are warnings", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("POST /orgs/my-org/repos", http.StatusCreated, created)
+		fake.reply("PUT /repos/my-org/service/topics", http.StatusForbidden, map[string]string{"message": "Must have admin rights to Repository."})
+		fake.reply("POST /repos/my-org/service/branches/main/rename", http.StatusCreated, github.Branch{Name: github.Ptr("trunk")})
+
+		w := serveJSON(t, app, "POST", "/repositories", request)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created since the repository exists")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, response.Warnings, 1, "Topic failure should be reported as a warning")
+		assert.Empty(t, response.Topics, "Topics should not be reported as set")
+		assert.Equal(t, "trunk", response.DefaultBranch, "Default branch should still be renamed")
+	})
+
+	t.Run("Internal visibility without organization", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/repositories", `{"name": "service", "visibility": "internal"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Default branch without auto init", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/repositories", `{"name": "service", "default_branch": "trunk"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+
+		var response models.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "default_branch requires auto_init", response.Error, "Error message should explain the missing auto_init")
+	})
+}
+
+func TestCreateRepositoryFromTemplate(t *testing.T) {
+	t.Run("Successfully create repository from template", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /repos/my-org/service-template/generate", func(w http.ResponseWriter, r *http.Request) {
+			var req map[string]any
+			readJSON(t, r, &req)
+			assert.Equal(t, map[string]any{
+				"name":                 "new-service",
+				"owner":                "my-org",
+				"description":          "bootstrapped service",
+				"private":              true,
+				"include_all_branches": true,
+			}, req, "Template request should match")
+
+			writeJSON(w, http.StatusCreated, github.Repository{
+				Name:          github.Ptr("new-service"),
+				Owner:         &github.User{Login: github.Ptr("my-org")},
+				Private:       github.Ptr(true),
+				DefaultBranch: github.Ptr("main"),
+			})
+		})
+
+		request := models.TemplateRepoRequest{
+			TemplateOwner:      "my-org",
+			TemplateRepo:       "service-template",
+			Name:               "new-service",
+			Owner:              "my-org",
+			Description:        "bootstrapped service",
+			Private:            true,
+			IncludeAllBranches: true,
+		}
+
+		requestBody, err := json.Marshal(request)
+		assert.NoError(t, err, errJSONMarshal)
+
+		w := serveJSON(t, app, "POST", "/repositories/from-template", string(requestBody))
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var response models.RepoResponse
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "new-service", response.Name, "Repository name should match")
+		assert.Equal(t, "my-org", response.Owner, "Repository owner should match")
+		assert.True(t, response.Private, "Repository should be private")
+		assert.Equal(t, "my-org/service-template", response.Template, "Template source should be echoed")
+	})
+
+	t.Run("Owner defaults to the configured owner", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /repos/my-org/service-template/generate", func(w http.ResponseWriter, r *http.Request) {
+			var req map[string]any
+			readJSON(t, r, &req)
+			assert.Equal(t, "owner", req["owner"], "Repository should be created under the configured owner")
+
+			writeJSON(w, http.StatusCreated, github.Repository{Name: github.Ptr("new-service"), Owner: &github.User{Login: github.Ptr("owner")}})
+		})
+
+		w := serveJSON(t, app, "POST", "/repositories/from-template", `{"template_owner": "my-org", "template_repo": "service-template", "name": "new-service"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+	})
+
+	t.Run("Source is not a template", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("POST /repos/my-org/plain-repo/generate", http.StatusUnprocessableEntity, map[string]string{"message": "plain-repo is not a template repository"})
+
+		w := serveJSON(t, app, "POST", "/repositories/from-template", `{"template_owner": "my-org", "template_repo": "plain-repo", "name": "new-service"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Code should be 422 UnprocessableEntity")
+	})
+
+	t.Run("Missing template", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "POST", "/repositories/from-template", `{"name": "new-service"}`)
+
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
 	})
 }
@@ -129,19 +334,19 @@ func TestListRepository(t *testing.T) {
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
-	
+
 		var repos []*github.Repository
 		err = json.Unmarshal(w.Body.Bytes(), &repos)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Len(t, repos, 2, "There should be 2 repositories listed")
 		assert.Equal(t, "test-repo", *repos[0].Name, "First repo name should be 'test-repo'")
 		assert.Equal(t, "hello-world", *repos[1].Name, "Second repo name should be 'hello-world'")
@@ -156,19 +361,19 @@ func TestListRepository(t *testing.T) {
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
-	
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+
 		var response map[string]string
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Equal(t, "mock error", response["error"], "Error message should be 'mock error'")
 	})
 
@@ -182,22 +387,229 @@ func TestListRepository(t *testing.T) {
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
-	
+
 		var repos []*github.Repository
 		err = json.Unmarshal(w.Body.Bytes(), &repos)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Len(t, repos, 0, "There should be no repositories listed")
 	})
-	
+}
+
+func TestListRepositoryOnGitHub(t *testing.T) {
+	pages := []any{
+		[]*github.Repository{{Name: github.Ptr("repo-1")}, {Name: github.Ptr("repo-2")}},
+		[]*github.Repository{{Name: github.Ptr("repo-3")}},
+	}
+
+	listRepos := func(t *testing.T, app *handlers.Application, query string) (*httptest.ResponseRecorder, []models.RepoSummary) {
+		w := serveJSON(t, app, "GET", "/repositories?"+query, "")
+
+		var repos []models.RepoSummary
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &repos)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, repos
+	}
+
+	t.Run("List a single page of repositories", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /user/repos", pages...)
+
+		w, repos := listRepos(t, app, "page=1&per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Len(t, repos, 2, "There should be 2 repositories in the first page")
+		assert.Equal(t, "2", w.Header().Get("X-Next-Page"), "Next page should be 2")
+		assert.Contains(t, w.Header().Get("Link"), `rel="next"`, "Link header should point to the next page")
+		assert.Contains(t, w.Header().Get("Link"), "/repositories?page=2&per_page=2", "Next link should keep per_page")
+	})
+
+	t.Run("Last page has no next link", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /user/repos", pages...)
+
+		w, repos := listRepos(t, app, "page=2&per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Len(t, repos, 1, "There should be 1 repository in the last page")
+		assert.Equal(t, "repo-3", repos[0].Name, "Last page should contain 'repo-3'")
+		assert.Empty(t, w.Header().Get("X-Next-Page"), "There should be no next page")
+		assert.Contains(t, w.Header().Get("Link"), `rel="prev"`, "Link header should point to the previous page")
+	})
+
+	t.Run("Full listing walks every page", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /user/repos", pages...)
+
+		w, repos := listRepos(t, app, "per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Len(t, repos, 3, "Every page should be listed")
+		assert.Empty(t, w.Header().Get("X-Truncated"), "Listing should not be truncated")
+	})
+
+	t.Run("Full listing is capped by MaxResults", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /user/repos", pages...)
+		app.SetMaxResults(2)
+
+		w, repos := listRepos(t, app, "per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Len(t, repos, 2, "Listing should stop at the cap")
+		assert.Equal(t, "true", w.Header().Get("X-Truncated"), "Truncated header should be set")
+	})
+
+	t.Run("Invalid per_page parameter", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w, _ := listRepos(t, app, "page=1&per_page=500")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+}
+
+func TestListRepositoryFilters(t *testing.T) {
+	repos := []*github.Repository{
+		{Name: github.Ptr("api-service"), Language: github.Ptr("Go"), Topics: []string{"backend"}},
+		{Name: github.Ptr("web-app"), Language: github.Ptr("TypeScript"), Topics: []string{"frontend"}},
+		{Name: github.Ptr("api-gateway"), Language: github.Ptr("Go"), Topics: []string{"backend", "edge"}},
+	}
+
+	// listRepos lists the repositories, checking the query GitHub receives against want
+	listRepos := func(t *testing.T, query string, want map[string]string) (*httptest.ResponseRecorder, []models.RepoSummary) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /user/repos", func(w http.ResponseWriter, r *http.Request) {
+			got := map[string]string{}
+			for key := range r.URL.Query() {
+				if key != "page" && key != "per_page" {
+					got[key] = r.URL.Query().Get(key)
+				}
+			}
+			assert.Equal(t, want, got, "GitHub should apply the type, visibility, sort and direction")
+
+			writeJSON(w, http.StatusOK, repos)
+		})
+
+		w := serveJSON(t, app, "GET", "/repositories?"+query, "")
+
+		var summaries []models.RepoSummary
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &summaries)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, summaries
+	}
+
+	t.Run("Filter by name, language and topic", func(t *testing.T) {
+		w, repos := listRepos(t, "name=API&language=go&topic=edge", map[string]string{"type": "owner", "sort": "full_name"})
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Len(t, repos, 1, "Only 1 repository should match")
+		assert.Equal(t, "api-gateway", repos[0].Name, "Matching repository should be 'api-gateway'")
+	})
+
+	t.Run("Filter by visibility", func(t *testing.T) {
+		w, _ := listRepos(t, "visibility=private&type=member", map[string]string{"visibility": "private", "affiliation": "collaborator,organization_member", "sort": "full_name"})
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+	})
+
+	t.Run("Sort by last push", func(t *testing.T) {
+		w, repos := listRepos(t, "sort=pushed&direction=desc", map[string]string{"type": "owner", "sort": "pushed", "direction": "desc"})
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []string{"api-service", "web-app", "api-gateway"}, []string{repos[0].Name, repos[1].Name, repos[2].Name}, "GitHub's order should be kept")
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"sort=stars", "type=forks", "visibility=internal", "archived=maybe"} {
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "GET", "/repositories?"+query, "")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for "+query)
+		}
+	})
+}
+
+func TestGetRepository(t *testing.T) {
+	t.Run("Successfully get repository details", func(t *testing.T) {
+		createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo", http.StatusOK, github.Repository{
+			Name:            github.Ptr("test-repo"),
+			FullName:        github.Ptr("owner/test-repo"),
+			Owner:           &github.User{Login: github.Ptr("owner")},
+			Visibility:      github.Ptr("public"),
+			DefaultBranch:   github.Ptr("main"),
+			StargazersCount: github.Ptr(10),
+			ForksCount:      github.Ptr(2),
+			License:         &github.License{Key: github.Ptr("mit")},
+			CreatedAt:       &github.Timestamp{Time: createdAt},
+			CloneURL:        github.Ptr("https://github.com/owner/test-repo.git"),
+			SSHURL:          github.Ptr("git@github.com:owner/test-repo.git"),
+			Permissions:     map[string]bool{"admin": true, "push": true, "pull": true},
+		})
+		fake.reply("GET /repos/owner/test-repo/languages", http.StatusOK, map[string]int{"Go": 750, "Shell": 250})
+		fake.reply("GET /repos/owner/test-repo/topics", http.StatusOK, map[string][]string{"names": {"go"}})
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.RepoDetail
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "owner/test-repo", response.FullName, "Full name should match")
+		assert.Equal(t, "owner", response.Owner, "Owner should match")
+		assert.Equal(t, 10, response.Stars, "Stars should match")
+		assert.Equal(t, "mit", response.License, "License should match")
+		assert.Equal(t, createdAt, response.CreatedAt, "Creation time should match")
+		assert.Equal(t, "git@github.com:owner/test-repo.git", response.CloneURLs.SSH, "SSH clone URL should match")
+		assert.True(t, response.Permissions["admin"], "Admin permission should be reported")
+		assert.Equal(t, []string{"go"}, response.Topics, "Topics should match")
+
+		assert.Len(t, response.Languages, 2, "There should be 2 languages")
+		assert.Equal(t, "Go", response.Languages[0].Name, "Largest language should come first")
+		assert.Equal(t, 75.0, response.Languages[0].Percent, "Go should be 75%")
+		assert.Equal(t, 25.0, response.Languages[1].Percent, "Shell should be 25%")
+	})
+
+	t.Run("Repository without topics", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo", http.StatusOK, github.Repository{Name: github.Ptr("test-repo")})
+		fake.reply("GET /repos/owner/test-repo/languages", http.StatusOK, map[string]int{})
+		fake.reply("GET /repos/owner/test-repo/topics", http.StatusOK, map[string][]string{"names": {}})
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Contains(t, w.Body.String(), `"topics":[]`, "Topics should be an empty list")
+	})
+
+	t.Run("Repository does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		notFound := map[string]string{"message": "Not Found"}
+		fake.reply("GET /repos/owner/hello-world", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/hello-world/languages", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/hello-world/topics", http.StatusNotFound, notFound)
+
+		w := serveJSON(t, app, "GET", "/repositories/hello-world", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
 }
 
 func TestDeleteRepository(t *testing.T) {
@@ -210,19 +622,19 @@ func TestDeleteRepository(t *testing.T) {
 		}
 
 		gin.SetMode(gin.TestMode)
-		
+
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("DELETE", "/repositories/test-repo", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
-	
+
 		var response models.DeleteRepoResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
@@ -244,24 +656,24 @@ func TestDeleteRepository(t *testing.T) {
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("DELETE", "/repositories/hello-world", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
-	
+
 		var response models.DeleteRepoResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Equal(t, "Repository not found", response.Message, "Error message should be 'Repository not found'")
 		assert.Len(t, mockClient.RepositoryList, 1, "There should still be 1 repository")
 		assert.Equal(t, "test-repo", *mockClient.RepositoryList[0].Name, "Remaining repository should be 'test-repo'")
 	})
-	
+
 	t.Run("Error while deleting repository", func(t *testing.T) {
 		mockClient := &handlers.GitHubMock{MockError: errors.New("mock error")}
 
@@ -270,267 +682,494 @@ func TestDeleteRepository(t *testing.T) {
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("DELETE", "/repositories/test-repo", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
-	
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 BadGateway")
+
 		var response map[string]string
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
 
 		assert.Equal(t, "mock error", response["error"], "Error message should be 'mock error'")
-	})	
+	})
+}
+
+func TestDeleteRepositoryGuard(t *testing.T) {
+	// repoOnGitHub serves a repository and its open PRs, as counted from the last page of a one per page listing
+	repoOnGitHub := func(fake *fakeGitHub, repo *github.Repository, openPRs int) {
+		fake.reply("GET /repos/owner/"+repo.GetName(), http.StatusOK, repo)
+		fake.handle("GET /repos/owner/"+repo.GetName()+"/pulls", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%d&per_page=1>; rel="last"`, r.Host, r.URL.Path, openPRs))
+			writeJSON(w, http.StatusOK, []*github.PullRequest{testPullRequest(1, "open", "alice", "feature", time.Now())})
+		})
+	}
+
+	// confirmation issues a delete confirmation for repo
+	confirmation := func(t *testing.T, app *handlers.Application, repo string) models.DeleteConfirmationResponse {
+		w := serveJSON(t, app, "DELETE", "/repositories/"+repo, "")
+		assert.Equal(t, http.StatusAccepted, w.Code, "Code should be 202 Accepted")
+
+		var confirmation models.DeleteConfirmationResponse
+		err := json.Unmarshal(w.Body.Bytes(), &confirmation)
+		assert.NoError(t, err, errJSONUnmarshal)
+		return confirmation
+	}
+
+	t.Run("Confirmation holds the repository state", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		repoOnGitHub(fake, &github.Repository{Name: github.Ptr("test-repo"), StargazersCount: github.Ptr(3)}, 4)
+
+		confirmation := confirmation(t, app, "test-repo")
+
+		assert.NotEmpty(t, confirmation.Token, "A confirmation token should be issued")
+		assert.Equal(t, 3, confirmation.Metadata.Stars, "Metadata should include the stars")
+		assert.Equal(t, 4, confirmation.Metadata.OpenPullRequests, "Metadata should include the open PRs")
+	})
+
+	t.Run("Token for another repository is rejected", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		repoOnGitHub(fake, &github.Repository{Name: github.Ptr("test-repo")}, 1)
+		repoOnGitHub(fake, &github.Repository{Name: github.Ptr("hello-world")}, 1)
+
+		confirmation := confirmation(t, app, "test-repo")
+		w := serveJSON(t, app, "DELETE", "/repositories/hello-world?confirm="+confirmation.Token, "")
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.False(t, fake.called("DELETE /repos/owner/hello-world"), "Nothing should be deleted")
+	})
+
+	t.Run("Token is invalidated when the repository changes", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		pushedAt := time.Now().Add(-time.Hour)
+		fake.handle("GET /repos/owner/test-repo", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, github.Repository{Name: github.Ptr("test-repo"), PushedAt: &github.Timestamp{Time: pushedAt}})
+		})
+		fake.reply("GET /repos/owner/test-repo/pulls", http.StatusOK, []*github.PullRequest{})
+
+		confirmation := confirmation(t, app, "test-repo")
+
+		// Someone pushes between the two calls
+		pushedAt = time.Now()
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo?confirm="+confirmation.Token, "")
+
+		assert.Equal(t, http.StatusConflict, w.Code, "Code should be 409 Conflict")
+		assert.False(t, fake.called("DELETE /repos/owner/test-repo"), "Repository should not be deleted")
+	})
+
+	for _, repo := range []string{"prod-api", "PROD-api", "Prod-Api"} {
+		t.Run("Protected repository named "+repo, func(t *testing.T) {
+			fake, app := newFakeGitHub(t)
+			assert.NoError(t, app.SetProtectedRepos("Prod-*"), "Pattern should be valid")
+
+			// Any request reaching GitHub fails the test
+			w := serveJSON(t, app, "DELETE", "/repositories/"+repo, "")
+
+			assert.Equal(t, http.StatusForbidden, w.Code, "Code should be 403 Forbidden whatever the case of the name")
+
+			var response models.ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+
+			assert.Equal(t, models.ErrCodeForbidden, response.Code, "Error code should be 'forbidden'")
+			assert.False(t, fake.called("DELETE /repos/owner/"+repo), "Repository should not be deleted")
+		})
+	}
+}
+
+func TestUpdateRepository(t *testing.T) {
+	t.Run("Successfully update repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("PATCH /repos/owner/test-repo", func(w http.ResponseWriter, r *http.Request) {
+			var edit map[string]any
+			readJSON(t, r, &edit)
+			assert.Equal(t, map[string]any{"name": "renamed-repo", "visibility": "private", "allow_squash_merge": true, "has_wiki": false}, edit, "Only the given fields should be sent")
+
+			writeJSON(w, http.StatusOK, github.Repository{
+				Name:             github.Ptr("renamed-repo"),
+				Description:      github.Ptr("old description"),
+				Private:          github.Ptr(true),
+				Visibility:       github.Ptr("private"),
+				AllowSquashMerge: github.Ptr(true),
+			})
+		})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/test-repo", `{"name": "renamed-repo", "visibility": "private", "allow_squash_merge": true, "has_wiki": false}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "Repository updated successfully", response.Message, "Message should match")
+		assert.Equal(t, "renamed-repo", response.Name, "Repository should be renamed")
+		assert.Equal(t, "old description", response.Description, "Description should be left untouched")
+		assert.Equal(t, "private", response.Visibility, "Visibility should be private")
+		assert.True(t, response.Private, "Repository should be private")
+		assert.True(t, response.AllowSquashMerge, "Squash merge should be allowed")
+	})
+
+	t.Run("Empty update", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "PATCH", "/repositories/test-repo", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+
+		var response models.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "No fields to update", response.Error, "Error message should be 'No fields to update'")
+	})
+
+	t.Run("Invalid visibility", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "PATCH", "/repositories/test-repo", `{"visibility": "secret"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Update non-existent repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("PATCH /repos/owner/hello-world", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w := serveJSON(t, app, "PATCH", "/repositories/hello-world", `{"description": "new"}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
+
+func TestArchiveRepository(t *testing.T) {
+	// archiveOnGitHub answers repository edits by echoing the archived flag it was sent
+	archiveOnGitHub := func(t *testing.T, fake *fakeGitHub) {
+		fake.handle("PATCH /repos/owner/test-repo", func(w http.ResponseWriter, r *http.Request) {
+			var edit map[string]any
+			readJSON(t, r, &edit)
+			assert.Len(t, edit, 1, "Only the archived flag should be sent")
+
+			archived, _ := edit["archived"].(bool)
+			writeJSON(w, http.StatusOK, github.Repository{Name: github.Ptr("test-repo"), Archived: github.Ptr(archived)})
+		})
+	}
+
+	t.Run("Archive and unarchive repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		archiveOnGitHub(t, fake)
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/archive", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var response models.RepoResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "Repository archived successfully", response.Message, "Message should match")
+		assert.True(t, response.Archived, "Repository should be archived")
+
+		w = serveJSON(t, app, "POST", "/repositories/test-repo/unarchive", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "Repository unarchived successfully", response.Message, "Message should match")
+		assert.False(t, response.Archived, "Repository should no longer be archived")
+	})
+
+	t.Run("Delete in archive mode keeps the repository", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		archiveOnGitHub(t, fake)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo?mode=archive", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.True(t, fake.called("PATCH /repos/owner/test-repo"), "Repository should be archived")
+		assert.False(t, fake.called("DELETE /repos/owner/test-repo"), "Repository should not be deleted")
+	})
+
+	t.Run("Invalid delete mode", func(t *testing.T) {
+		_, app := newFakeGitHub(t)
+
+		w := serveJSON(t, app, "DELETE", "/repositories/test-repo?mode=shred", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
+	})
+
+	t.Run("Filter repositories by archived status", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /user/repos", http.StatusOK, []*github.Repository{
+			{Name: github.Ptr("active-repo"), Archived: github.Ptr(false)},
+			{Name: github.Ptr("old-repo"), Archived: github.Ptr(true)},
+		})
+
+		w := serveJSON(t, app, "GET", "/repositories?archived=true", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var repos []models.RepoSummary
+		err := json.Unmarshal(w.Body.Bytes(), &repos)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, repos, 1, "There should be 1 archived repository")
+		assert.Equal(t, "old-repo", repos[0].Name, "Archived repository should be 'old-repo'")
+		assert.True(t, repos[0].Archived, "Repository should be flagged as archived")
+	})
 }
 
 func TestListOpenPullRequests(t *testing.T) {
 	t.Run("Successfully list open pull requests with no limit", func(t *testing.T) {
 		mockPRs := []*github.PullRequest{
-            {
-                Number:    github.Ptr(1),
-                Title:     github.Ptr("First PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
-            {
-                Number:    github.Ptr(2),
-                Title:     github.Ptr("Second PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/2"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
-        }
-		
+			{
+				Number:    github.Ptr(1),
+				Title:     github.Ptr("First PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
+			{
+				Number:    github.Ptr(2),
+				Title:     github.Ptr("Second PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/2"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
+		}
+
 		mockClient := &handlers.GitHubMock{
-            RepositoryList: []*github.Repository{
-                {Name: github.Ptr("test-repo")}, // Add the repository to RepositoryList
-            },
-            PRList: mockPRs,
-        }
+			RepositoryList: []*github.Repository{
+				{Name: github.Ptr("test-repo")}, // Add the repository to RepositoryList
+			},
+			PRList: mockPRs,
+		}
 
 		gin.SetMode(gin.TestMode)
 
 		r := gin.Default()
-        ghClient := handlers.GetClientForTest(mockClient)
-        routes.SetupRoutes(r, *ghClient)
+		ghClient := handlers.GetClientForTest(mockClient)
+		routes.SetupRoutes(r, *ghClient)
 
-        req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests", nil)
-        assert.NoError(t, err, errRequestCreate)
+		req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests", nil)
+		assert.NoError(t, err, errRequestCreate)
 
-        w := httptest.NewRecorder()
-        r.ServeHTTP(w, req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
 
-        assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
 
-        var response []models.PullRequestResponse
-        err = json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, errJSONUnmarshal)
+		var response []models.PullRequestResponse
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
 
-        assert.Len(t, response, 2, "There should be 2 pull requests")
+		assert.Len(t, response, 2, "There should be 2 pull requests")
 
-        // Assert first PR fields
-        assert.Equal(t, 1, response[0].Number, "First PR number should match")
-        assert.Equal(t, "First PR", response[0].Title, "First PR title should match")
-        assert.Equal(t, "testuser", response[0].User, "First PR user should match")
-        assert.Equal(t, "https://github.com/test/test-repo/pull/1", response[0].HtmlURL, "First PR URL should match")
-        assert.Equal(t, mockPRs[0].GetCreatedAt().Time.UTC(), response[0].CreatedAt, "First PR creation time should match")
+		// Assert first PR fields
+		assert.Equal(t, 1, response[0].Number, "First PR number should match")
+		assert.Equal(t, "First PR", response[0].Title, "First PR title should match")
+		assert.Equal(t, "testuser", response[0].User, "First PR user should match")
+		assert.Equal(t, "https://github.com/test/test-repo/pull/1", response[0].HtmlURL, "First PR URL should match")
+		assert.Equal(t, mockPRs[0].GetCreatedAt().Time.UTC(), response[0].CreatedAt, "First PR creation time should match")
 
-        // Assert second PR fields
-        assert.Equal(t, 2, response[1].Number, "Second PR number should match")
-        assert.Equal(t, "Second PR", response[1].Title, "Second PR title should match")
-        assert.Equal(t, "testuser", response[1].User, "Second PR user should match")
-        assert.Equal(t, "https://github.com/test/test-repo/pull/2", response[1].HtmlURL, "Second PR URL should match")
-        assert.Equal(t, mockPRs[1].GetCreatedAt().Time.UTC(), response[1].CreatedAt, "Second PR creation time should match")
-    })
+		// Assert second PR fields
+		assert.Equal(t, 2, response[1].Number, "Second PR number should match")
+		assert.Equal(t, "Second PR", response[1].Title, "Second PR title should match")
+		assert.Equal(t, "testuser", response[1].User, "Second PR user should match")
+		assert.Equal(t, "https://github.com/test/test-repo/pull/2", response[1].HtmlURL, "Second PR URL should match")
+		assert.Equal(t, mockPRs[1].GetCreatedAt().Time.UTC(), response[1].CreatedAt, "Second PR creation time should match")
+	})
 
 	t.Run("Successfully list open pull requests with limit", func(t *testing.T) {
 		mockPRs := []*github.PullRequest{
-            {
-                Number:    github.Ptr(1),
-                Title:     github.Ptr("First PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
-            {
-                Number:    github.Ptr(2),
-                Title:     github.Ptr("Second PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/2"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
 			{
-                Number:    github.Ptr(3),
-                Title:     github.Ptr("Third PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/3"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
-        }
+				Number:    github.Ptr(1),
+				Title:     github.Ptr("First PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
+			{
+				Number:    github.Ptr(2),
+				Title:     github.Ptr("Second PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/2"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
+			{
+				Number:    github.Ptr(3),
+				Title:     github.Ptr("Third PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/3"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
+		}
 
 		mockClient := &handlers.GitHubMock{
-            RepositoryList: []*github.Repository{
-                {Name: github.Ptr("test-repo")}, 
-            },
-            PRList: mockPRs,
-        }
+			RepositoryList: []*github.Repository{
+				{Name: github.Ptr("test-repo")},
+			},
+			PRList: mockPRs,
+		}
 
 		gin.SetMode(gin.TestMode)
 
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests?limit=2", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
-	
+
 		var response []models.PullRequestResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Len(t, response, 2, "There should be 2 pull requests")
 
 		assert.Equal(t, 1, response[0].Number, "First PR number should match")
 		assert.Equal(t, "First PR", response[0].Title, "First PR title should match")
 		assert.Equal(t, "testuser", response[0].User, "First PR user should match")
 		assert.Equal(t, "https://github.com/test/test-repo/pull/1", response[0].HtmlURL, "First PR URL should match")
-		assert.Equal(t, mockPRs[0].GetCreatedAt().Time.UTC().Truncate(time.Second), 
-					 response[0].CreatedAt.UTC().Truncate(time.Second), 
-					 "First PR creation time should match")
-	
+		assert.Equal(t, mockPRs[0].GetCreatedAt().Time.UTC().Truncate(time.Second),
+			response[0].CreatedAt.UTC().Truncate(time.Second),
+			"First PR creation time should match")
+
 		assert.Equal(t, 2, response[1].Number, "Second PR number should match")
 		assert.Equal(t, "Second PR", response[1].Title, "Second PR title should match")
 		assert.Equal(t, "testuser", response[1].User, "Second PR user should match")
 		assert.Equal(t, "https://github.com/test/test-repo/pull/2", response[1].HtmlURL, "Second PR URL should match")
-		assert.Equal(t, mockPRs[1].GetCreatedAt().Time.UTC().Truncate(time.Second), 
-					 response[1].CreatedAt.UTC().Truncate(time.Second), 
-					 "Second PR creation time should match")
+		assert.Equal(t, mockPRs[1].GetCreatedAt().Time.UTC().Truncate(time.Second),
+			response[1].CreatedAt.UTC().Truncate(time.Second),
+			"Second PR creation time should match")
 	})
-	
+
 	t.Run("Invalid limit parameter", func(t *testing.T) {
 		mockPRs := []*github.PullRequest{
-            {
-                Number:    github.Ptr(1),
-                Title:     github.Ptr("First PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
+			{
+				Number:    github.Ptr(1),
+				Title:     github.Ptr("First PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
 		}
 
 		mockClient := &handlers.GitHubMock{
-            PRList: mockPRs,
-        }
+			PRList: mockPRs,
+		}
 
 		gin.SetMode(gin.TestMode)
 
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests?limit=invalid", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest")
-	
+
 		var response map[string]string
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Equal(t, "Invalid limit value", response["error"], "Error message should be 'Invalid limit value'")
 	})
-	
+
 	t.Run("Repository does not exist", func(t *testing.T) {
 		mockPRs := []*github.PullRequest{
-            {
-                Number:    github.Ptr(1),
-                Title:     github.Ptr("First PR"),
-                State:     github.Ptr("open"),
-                HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
-                CreatedAt: &github.Timestamp{Time: time.Now()},
-                User:      &github.User{Login: github.Ptr("testuser")},
-                Base: &github.PullRequestBranch{
-                    Repo: &github.Repository{
-                        Name: github.Ptr("test-repo"),
-                    },
-                },
-            },
+			{
+				Number:    github.Ptr(1),
+				Title:     github.Ptr("First PR"),
+				State:     github.Ptr("open"),
+				HTMLURL:   github.Ptr("https://github.com/test/test-repo/pull/1"),
+				CreatedAt: &github.Timestamp{Time: time.Now()},
+				User:      &github.User{Login: github.Ptr("testuser")},
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{
+						Name: github.Ptr("test-repo"),
+					},
+				},
+			},
 		}
 
 		mockClient := &handlers.GitHubMock{
-            PRList: mockPRs,
-        }
+			PRList: mockPRs,
+		}
 
 		gin.SetMode(gin.TestMode)
 
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories/hello-world/pull-requests", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
-	
+
 		var response map[string]string
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Equal(t, "Repository 'hello-world' does not exist", response["error"], "Error message should be: 'Repository 'hello-world' does not exist'")
 	})
-	
+
 	t.Run("No pull requests for repository", func(t *testing.T) {
 		mockPRs := []*github.PullRequest{}
 		mockRepo := []*github.Repository{
@@ -538,54 +1177,119 @@ func TestListOpenPullRequests(t *testing.T) {
 		}
 
 		mockClient := &handlers.GitHubMock{
-            PRList: mockPRs,
+			PRList:         mockPRs,
 			RepositoryList: mockRepo,
-        }
+		}
 
 		gin.SetMode(gin.TestMode)
 
 		r := gin.Default()
 		ghClient := handlers.GetClientForTest(mockClient)
 		routes.SetupRoutes(r, *ghClient)
-	
+
 		req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests", nil)
 		assert.NoError(t, err, errRequestCreate)
-	
+
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
-	
+
 		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
-	
+
 		var response []models.PullRequestResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err, errJSONUnmarshal)
-	
+
 		assert.Len(t, response, 0, "There should be 0 pull requests")
 	})
 
 	t.Run("Error listing pull requests", func(t *testing.T) {
-        mockClient := &handlers.GitHubMock{
-            MockError: errors.New("failed to fetch pull requests"),
-        }
+		mockClient := &handlers.GitHubMock{
+			MockError: errors.New("failed to fetch pull requests"),
+		}
 
 		gin.SetMode(gin.TestMode)
-        
-        r := gin.Default()
-        ghClient := handlers.GetClientForTest(mockClient)
-        routes.SetupRoutes(r, *ghClient)
 
-        req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests", nil)
-        assert.NoError(t, err, errRequestCreate)
+		r := gin.Default()
+		ghClient := handlers.GetClientForTest(mockClient)
+		routes.SetupRoutes(r, *ghClient)
 
-        w := httptest.NewRecorder()
-        r.ServeHTTP(w, req)
+		req, err := http.NewRequest("GET", "/repositories/test-repo/pull-requests", nil)
+		assert.NoError(t, err, errRequestCreate)
 
-        assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 Bad Request")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code, "Code should be 502 Bad Gateway")
 
-        var response map[string]string
-        err = json.Unmarshal(w.Body.Bytes(), &response)
-        assert.NoError(t, err, errJSONUnmarshal)
+		var response map[string]string
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err, errJSONUnmarshal)
 
-        assert.Equal(t, "failed to fetch pull requests", response["error"], "Error message should match mock error")
-    })
+		assert.Equal(t, "failed to fetch pull requests", response["error"], "Error message should match mock error")
+	})
+}
+
+func TestListOpenPullRequestsOnGitHub(t *testing.T) {
+	created := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	pages := []any{
+		[]*github.PullRequest{testPullRequest(3, "open", "alice", "third", created), testPullRequest(2, "open", "bob", "second", created)},
+		[]*github.PullRequest{testPullRequest(1, "open", "alice", "first", created)},
+	}
+
+	listPRs := func(t *testing.T, app *handlers.Application, query string) (*httptest.ResponseRecorder, []models.PullRequestResponse) {
+		w := serveJSON(t, app, "GET", "/repositories/api/pull-requests?"+query, "")
+
+		var response []models.PullRequestResponse
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err, errJSONUnmarshal)
+		}
+		return w, response
+	}
+
+	t.Run("List a single page of pull requests", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /repos/owner/api/pulls", pages...)
+
+		w, response := listPRs(t, app, "page=1&per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{3, 2}, numbersOf(response), "First page should be listed")
+		assert.Equal(t, "2", w.Header().Get("X-Next-Page"), "Next page should be 2")
+		assert.Contains(t, w.Header().Get("Link"), "/repositories/api/pull-requests?page=2&per_page=2", "Next link should keep per_page")
+	})
+
+	t.Run("Full listing walks every page", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.replyPages("GET /repos/owner/api/pulls", pages...)
+
+		w, response := listPRs(t, app, "per_page=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{3, 2, 1}, numbersOf(response), "Every page should be listed")
+	})
+
+	t.Run("Limit stops walking pages", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("GET /repos/owner/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "1", r.URL.Query().Get("page"), "Only the first page should be fetched")
+			w.Header().Set("Link", `<http://`+r.Host+`/repos/owner/api/pulls?page=2>; rel="next"`)
+			writeJSON(w, http.StatusOK, pages[0])
+		})
+
+		w, response := listPRs(t, app, "per_page=2&limit=2")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, []int{3, 2}, numbersOf(response), "Listing should stop at the limit")
+		assert.Empty(t, w.Header().Get("X-Truncated"), "A limit should not be reported as truncated")
+	})
+
+	t.Run("Repository does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/api/pulls", http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+		w, _ := listPRs(t, app, "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
 }
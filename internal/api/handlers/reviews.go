@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// CreateReview approves, requests changes on or comments on a pull request, optionally with inline comments
+func (a *Application) CreateReview(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	if err := validateReviewRequest(req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	review, _, err := a.githubClient.PullRequests.CreateReview(ctx, a.owner, repo, number, newReviewFromRequest(req))
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newReviewResponse(review))
+}
+
+// ListReviews lists the reviews submitted on a pull request
+func (a *Application) ListReviews(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	reviews, err := fetchPages(c, p, a.maxResults, func(opts github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+		return a.githubClient.PullRequests.ListReviews(ctx, a.owner, repo, number, &opts)
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newReviewResponses(reviews))
+}
+
+// ListReviewComments lists the inline comments left on a pull request
+func (a *Application) ListReviewComments(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	p, err := parsePagination(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	comments, err := fetchPages(c, p, a.maxResults, func(opts github.ListOptions) ([]*github.PullRequestComment, *github.Response, error) {
+		return a.githubClient.PullRequests.ListComments(ctx, a.owner, repo, number, &github.PullRequestListCommentsOptions{ListOptions: opts})
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newReviewCommentResponses(comments))
+}
+
+// DismissReview dismisses a review, the message explains why
+func (a *Application) DismissReview(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+	reviewID, err := parseReviewID(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	var req models.DismissReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	review, _, err := a.githubClient.PullRequests.DismissReview(ctx, a.owner, repo, number, reviewID, &github.PullRequestReviewDismissalRequest{
+		Message: github.Ptr(req.Message),
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newReviewResponse(review))
+}
+
+// RequestReviewers requests reviews from users and teams
+func (a *Application) RequestReviewers(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	req, err := bindReviewersRequest(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	pr, _, err := a.githubClient.PullRequests.RequestReviewers(ctx, a.owner, repo, number, github.ReviewersRequest{
+		Reviewers:     req.Reviewers,
+		TeamReviewers: req.TeamReviewers,
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPullRequestResponse(pr))
+}
+
+// RemoveReviewers withdraws pending review requests from users and teams
+func (a *Application) RemoveReviewers(c *gin.Context) {
+	repo := c.Param("repo")
+	number, err := parsePRNumber(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	req, err := bindReviewersRequest(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	_, err = a.githubClient.PullRequests.RemoveReviewers(ctx, a.owner, repo, number, github.ReviewersRequest{
+		Reviewers:     req.Reviewers,
+		TeamReviewers: req.TeamReviewers,
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	// GitHub does not return the pull request after removing reviewers
+	pr, _, err := a.githubClient.PullRequests.Get(ctx, a.owner, repo, number)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPullRequestResponse(pr))
+}
+
+// validateReviewRequest checks what the binding tags cannot express
+func validateReviewRequest(req models.ReviewRequest) error {
+	if req.Event != "APPROVE" && req.Body == "" {
+		return errors.New("Body is required to request changes or comment")
+	}
+	for _, comment := range req.Comments {
+		if comment.StartSide != "" && comment.StartLine == 0 {
+			return errors.New("start_side requires start_line")
+		}
+	}
+	return nil
+}
+
+// newReviewFromRequest converts the request into the review sent to GitHub
+func newReviewFromRequest(req models.ReviewRequest) *github.PullRequestReviewRequest {
+	review := &github.PullRequestReviewRequest{
+		Event: github.Ptr(req.Event),
+	}
+	if req.Body != "" {
+		review.Body = github.Ptr(req.Body)
+	}
+	if req.CommitID != "" {
+		review.CommitID = github.Ptr(req.CommitID)
+	}
+
+	for _, comment := range req.Comments {
+		draft := &github.DraftReviewComment{
+			Path: github.Ptr(comment.Path),
+			Line: github.Ptr(comment.Line),
+			Body: github.Ptr(comment.Body),
+			Side: github.Ptr("RIGHT"),
+		}
+		if comment.Side != "" {
+			draft.Side = github.Ptr(comment.Side)
+		}
+		if comment.StartLine != 0 {
+			draft.StartLine = github.Ptr(comment.StartLine)
+			draft.StartSide = draft.Side
+			if comment.StartSide != "" {
+				draft.StartSide = github.Ptr(comment.StartSide)
+			}
+		}
+		review.Comments = append(review.Comments, draft)
+	}
+	return review
+}
+
+// bindReviewersRequest reads the reviewers to request or remove, at least one is required
+func bindReviewersRequest(c *gin.Context) (models.ReviewersRequest, error) {
+	var req models.ReviewersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return models.ReviewersRequest{}, err
+	}
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return models.ReviewersRequest{}, errors.New("At least one reviewer or team reviewer is required")
+	}
+	return req, nil
+}
+
+// parseReviewID reads the ':review_id' URL parameter
+func parseReviewID(c *gin.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("review_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("Invalid review ID")
+	}
+	return id, nil
+}
+
+// newReviewResponse converts a GitHub review into the simplified format
+func newReviewResponse(review *github.PullRequestReview) models.ReviewResponse {
+	return models.ReviewResponse{
+		ID:          review.GetID(),
+		Reviewer:    review.GetUser().GetLogin(),
+		State:       review.GetState(),
+		Body:        review.GetBody(),
+		CommitID:    review.GetCommitID(),
+		SubmittedAt: review.GetSubmittedAt().Time,
+		HtmlURL:     review.GetHTMLURL(),
+	}
+}
+
+// newReviewResponses converts a list of GitHub reviews
+func newReviewResponses(reviews []*github.PullRequestReview) []models.ReviewResponse {
+	formatted := make([]models.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		formatted = append(formatted, newReviewResponse(review))
+	}
+	return formatted
+}
+
+// newReviewCommentResponses converts a list of GitHub review comments
+func newReviewCommentResponses(comments []*github.PullRequestComment) []models.ReviewCommentResponse {
+	formatted := make([]models.ReviewCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		formatted = append(formatted, models.ReviewCommentResponse{
+			ID:        comment.GetID(),
+			ReviewID:  comment.GetPullRequestReviewID(),
+			InReplyTo: comment.GetInReplyTo(),
+			User:      comment.GetUser().GetLogin(),
+			Path:      comment.GetPath(),
+			Line:      comment.GetLine(),
+			Side:      comment.GetSide(),
+			StartLine: comment.GetStartLine(),
+			Body:      comment.GetBody(),
+			CommitID:  comment.GetCommitID(),
+			CreatedAt: comment.GetCreatedAt().Time,
+			HtmlURL:   comment.GetHTMLURL(),
+		})
+	}
+	return formatted
+}
@@ -0,0 +1,203 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/api/routes"
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveJSON sends a request with an optional JSON body to a router backed by app
+func serveJSON(t *testing.T, app handlers.ApplicationInterface, method, url, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.Default()
+	ghClient := handlers.GetClientForTest(app)
+	routes.SetupRoutes(r, *ghClient)
+
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	assert.NoError(t, err, errRequestCreate)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReviews(t *testing.T) {
+	notFound := map[string]string{"message": "Not Found"}
+
+	t.Run("Submit a review with inline comments", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /repos/owner/test-repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+			var review github.PullRequestReviewRequest
+			readJSON(t, r, &review)
+
+			assert.Equal(t, "REQUEST_CHANGES", review.GetEvent(), "Event should be sent to GitHub")
+			assert.Equal(t, "A few issues", review.GetBody(), "Body should be sent to GitHub")
+			assert.Len(t, review.Comments, 2, "Both comments should be sent to GitHub")
+			assert.Equal(t, "RIGHT", review.Comments[0].GetSide(), "Side should default to 'RIGHT'")
+			assert.Nil(t, review.Comments[0].StartLine, "Single line comment should not have a start line")
+			assert.Equal(t, 20, review.Comments[1].GetStartLine(), "Start line should match")
+			assert.Equal(t, "LEFT", review.Comments[1].GetStartSide(), "Start side should default to the side")
+
+			writeJSON(w, http.StatusOK, github.PullRequestReview{
+				ID:       github.Ptr(int64(7)),
+				User:     &github.User{Login: github.Ptr("bob")},
+				State:    github.Ptr("CHANGES_REQUESTED"),
+				CommitID: github.Ptr("abc123"),
+			})
+		})
+		fake.reply("GET /repos/owner/test-repo/pulls/1/comments", http.StatusOK, []*github.PullRequestComment{
+			{ID: github.Ptr(int64(1)), PullRequestReviewID: github.Ptr(int64(7)), Path: github.Ptr("main.go"), Line: github.Ptr(12), Side: github.Ptr("RIGHT")},
+			{ID: github.Ptr(int64(2)), PullRequestReviewID: github.Ptr(int64(7)), Path: github.Ptr("main.go"), Line: github.Ptr(25), StartLine: github.Ptr(20), Side: github.Ptr("LEFT")},
+		})
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/pull-requests/1/reviews", `{
+			"event": "REQUEST_CHANGES",
+			"body": "A few issues",
+			"comments": [
+				{"path": "main.go", "line": 12, "body": "Handle this error"},
+				{"path": "main.go", "start_line": 20, "line": 25, "side": "LEFT", "body": "Why remove this?"}
+			]
+		}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code, "Code should be 201 Created")
+
+		var review models.ReviewResponse
+		err := json.Unmarshal(w.Body.Bytes(), &review)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, "CHANGES_REQUESTED", review.State, "Review state should be 'CHANGES_REQUESTED'")
+		assert.Equal(t, "abc123", review.CommitID, "Review should be on the head commit")
+		assert.Equal(t, "bob", review.Reviewer, "Reviewer should match")
+
+		w = serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/comments", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var comments []models.ReviewCommentResponse
+		err = json.Unmarshal(w.Body.Bytes(), &comments)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, comments, 2, "There should be 2 review comments")
+		assert.Equal(t, review.ID, comments[0].ReviewID, "Comments should belong to the review")
+		assert.Equal(t, "RIGHT", comments[0].Side, "Side should match")
+		assert.Equal(t, 20, comments[1].StartLine, "Start line should match")
+		assert.Equal(t, "LEFT", comments[1].Side, "Side should match")
+	})
+
+	t.Run("Invalid reviews", func(t *testing.T) {
+		for _, body := range []string{
+			`{"event": "MERGE"}`,
+			`{"event": "COMMENT"}`,
+			`{"event": "APPROVE", "comments": [{"path": "main.go", "body": "No line"}]}`,
+			`{"event": "APPROVE", "comments": [{"path": "main.go", "line": 5, "start_line": 9, "body": "Backwards"}]}`,
+		} {
+			// Nothing is registered, invalid reviews must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "POST", "/repositories/test-repo/pull-requests/1/reviews", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for "+body)
+		}
+	})
+
+	t.Run("List and dismiss reviews", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.handle("PUT /repos/owner/test-repo/pulls/1/reviews/1/dismissals", func(w http.ResponseWriter, r *http.Request) {
+			var dismissal github.PullRequestReviewDismissalRequest
+			readJSON(t, r, &dismissal)
+			assert.Equal(t, "Approved too early", dismissal.GetMessage(), "Message should be sent to GitHub")
+
+			writeJSON(w, http.StatusOK, github.PullRequestReview{ID: github.Ptr(int64(1)), State: github.Ptr("DISMISSED")})
+		})
+		fake.reply("PUT /repos/owner/test-repo/pulls/1/reviews/9/dismissals", http.StatusNotFound, notFound)
+		fake.reply("GET /repos/owner/test-repo/pulls/1/reviews", http.StatusOK, []*github.PullRequestReview{
+			{ID: github.Ptr(int64(1)), User: &github.User{Login: github.Ptr("bob")}, State: github.Ptr("DISMISSED")},
+		})
+
+		w := serveJSON(t, app, "PUT", "/repositories/test-repo/pull-requests/1/reviews/1/dismissals", `{"message": "Approved too early"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Contains(t, w.Body.String(), "DISMISSED", "Dismissed review should be returned")
+
+		w = serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/1/reviews", "")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var reviews []models.ReviewResponse
+		err := json.Unmarshal(w.Body.Bytes(), &reviews)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Len(t, reviews, 1, "There should be 1 review")
+		assert.Equal(t, "DISMISSED", reviews[0].State, "Review should be dismissed")
+
+		w = serveJSON(t, app, "PUT", "/repositories/test-repo/pull-requests/1/reviews/1/dismissals", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Dismissing without a message should be 400 BadRequest")
+
+		w = serveJSON(t, app, "PUT", "/repositories/test-repo/pull-requests/1/reviews/abc/dismissals", `{"message": "Gone"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Invalid review ID should be 400 BadRequest")
+
+		w = serveJSON(t, app, "PUT", "/repositories/test-repo/pull-requests/1/reviews/9/dismissals", `{"message": "Gone"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code, "Dismissing an unknown review should be 404 NotFound")
+	})
+
+	t.Run("Request and remove reviewers", func(t *testing.T) {
+		withReviewers := func(logins ...string) *github.PullRequest {
+			pr := testPullRequest(1, "open", "alice", "feature", time.Now())
+			for _, login := range logins {
+				pr.RequestedReviewers = append(pr.RequestedReviewers, &github.User{Login: github.Ptr(login)})
+			}
+			return pr
+		}
+
+		fake, app := newFakeGitHub(t)
+		fake.handle("POST /repos/owner/test-repo/pulls/1/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+			var request github.ReviewersRequest
+			readJSON(t, r, &request)
+			assert.Equal(t, []string{"bob", "carol"}, request.Reviewers, "Reviewers should be sent to GitHub")
+
+			writeJSON(w, http.StatusCreated, withReviewers(request.Reviewers...))
+		})
+		fake.handle("DELETE /repos/owner/test-repo/pulls/1/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+			var request github.ReviewersRequest
+			readJSON(t, r, &request)
+			assert.Equal(t, []string{"bob"}, request.Reviewers, "Reviewers should be sent to GitHub")
+
+			writeJSON(w, http.StatusOK, withReviewers("carol"))
+		})
+		fake.reply("GET /repos/owner/test-repo/pulls/1", http.StatusOK, withReviewers("carol"))
+
+		w := serveJSON(t, app, "POST", "/repositories/test-repo/pull-requests/1/requested-reviewers", `{"reviewers": ["bob", "carol"]}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		w = serveJSON(t, app, "DELETE", "/repositories/test-repo/pull-requests/1/requested-reviewers", `{"reviewers": ["bob"]}`)
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var pr models.PullRequestResponse
+		err := json.Unmarshal(w.Body.Bytes(), &pr)
+		assert.NoError(t, err, errJSONUnmarshal)
+
+		assert.Equal(t, []string{"carol"}, pr.Reviewers, "Only carol should remain requested")
+		assert.True(t, fake.called("GET /repos/owner/test-repo/pulls/1"), "PR should be fetched again after removing reviewers")
+
+		w = serveJSON(t, app, "POST", "/repositories/test-repo/pull-requests/1/requested-reviewers", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Requesting nobody should be 400 BadRequest")
+	})
+
+	t.Run("Pull request does not exist", func(t *testing.T) {
+		fake, app := newFakeGitHub(t)
+		fake.reply("GET /repos/owner/test-repo/pulls/2/reviews", http.StatusNotFound, notFound)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/2/reviews", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Code should be 404 NotFound")
+	})
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-github/v68/github"
+)
+
+// Pull requests opened longer ago than this are checked when no 'older_than' is given
+const defaultStaleAge = "14d"
+
+// staleQuery holds the stale report options
+type staleQuery struct {
+	OlderThan string
+	Cutoff    time.Time
+	Format    string
+}
+
+// ListStalePullRequests reports the stale open pull requests of a repository
+func (a *Application) ListStalePullRequests(c *gin.Context) {
+	repo := c.Param("repo")
+	now := time.Now()
+
+	q, err := parseStaleQuery(c, now)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	stale, err := a.staleInRepo(ctx, a.owner, repo, q.Cutoff, now, fanOutConcurrency)
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	respondWithStaleReport(c, q, newStaleReport(q, stale, nil, now))
+}
+
+// ListAccountStalePullRequests reports the stale open pull requests across every repository
+// The repositories are picked with the same filters as ListRepositories, failures are reported per repository
+func (a *Application) ListAccountStalePullRequests(c *gin.Context) {
+	now := time.Now()
+
+	q, err := parseStaleQuery(c, now)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	filter, err := parseRepoFilter(c)
+	if err != nil {
+		respondWithBadRequest(c, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	repos, _, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return a.githubClient.Repositories.ListByAuthenticatedUser(ctx, filter.listOptions(listOpts))
+	})
+	if err != nil {
+		respondWithGitHubError(c, err)
+		return
+	}
+
+	repos = filter.apply(repos)
+
+	// Repositories are already checked in parallel, so each one checks its pull requests sequentially
+	// Member repositories belong to other accounts, so each one is queried under its own owner
+	results, errs := fanOut(repos, fanOutConcurrency, func(repo *github.Repository) ([]models.StalePullRequest, error) {
+		return a.staleInRepo(ctx, a.ownerOf(repo), repo.GetName(), q.Cutoff, now, 1)
+	})
+
+	stale, repoErrors := collectRepoResults(repos, results, errs)
+	respondWithStaleReport(c, q, newStaleReport(q, stale, repoErrors, now))
+}
+
+// staleInRepo finds the stale open pull requests of a repository
+// Reviews and checks are only fetched for pull requests opened before the cutoff
+func (a *Application) staleInRepo(ctx context.Context, owner, repo string, cutoff, now time.Time, concurrency int) ([]models.StalePullRequest, error) {
+	prs, _, err := fetchAllPages(maxPerPage, a.maxResults, func(listOpts github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		return a.githubClient.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{State: "open", ListOptions: listOpts})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := staleCandidates(prs, cutoff)
+	reasons, errs := fanOut(candidates, concurrency, func(pr *github.PullRequest) ([]string, error) {
+		var reviews []*github.PullRequestReview
+		if !hasRequestedReviewers(pr) {
+			var err error
+			// A single review is enough to know the pull request has reviewers
+			reviews, _, err = a.githubClient.PullRequests.ListReviews(ctx, owner, repo, pr.GetNumber(), &github.ListOptions{PerPage: 1})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		checks, err := a.headCheckStates(ctx, owner, repo, pr.GetHead().GetSHA())
+		if err != nil {
+			return nil, err
+		}
+		return staleReasons(pr, reviews, checks, cutoff), nil
+	})
+
+	return collectStale(owner+"/"+repo, candidates, reasons, errs, now)
+}
+
+// parseStaleQuery reads the 'older_than' and 'format' query parameters
+func parseStaleQuery(c *gin.Context, now time.Time) (staleQuery, error) {
+	q := staleQuery{
+		OlderThan: c.DefaultQuery("older_than", defaultStaleAge),
+		Format:    c.DefaultQuery("format", "json"),
+	}
+	if err := oneOf("format", q.Format, "json", "csv"); err != nil {
+		return staleQuery{}, err
+	}
+
+	age, err := parseAge(q.OlderThan)
+	if err != nil {
+		return staleQuery{}, err
+	}
+	q.Cutoff = now.Add(-age)
+
+	return q, nil
+}
+
+// parseAge reads an age such as '14d', '2w' or '36h'
+func parseAge(value string) (time.Duration, error) {
+	invalid := errors.New("Invalid older_than parameter, expected a duration such as 14d, 2w or 36h")
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if count, found := strings.CutSuffix(value, suffix); found {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return 0, invalid
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age <= 0 {
+		return 0, invalid
+	}
+	return age, nil
+}
+
+// staleCandidates keeps the open pull requests opened before the cutoff
+func staleCandidates(prs []*github.PullRequest, cutoff time.Time) []*github.PullRequest {
+	var candidates []*github.PullRequest
+	for _, pr := range prs {
+		if pr.GetState() == "open" && pr.GetCreatedAt().Before(cutoff) {
+			candidates = append(candidates, pr)
+		}
+	}
+	return candidates
+}
+
+// staleReasons explains why a pull request is stale, empty when it is not
+func staleReasons(pr *github.PullRequest, reviews []*github.PullRequestReview, checks map[string]string, cutoff time.Time) []string {
+	var reasons []string
+	if pr.GetUpdatedAt().Before(cutoff) {
+		reasons = append(reasons, models.StaleInactive)
+	}
+	if !hasRequestedReviewers(pr) && len(reviews) == 0 {
+		reasons = append(reasons, models.StaleNoReviewers)
+	}
+	if newCheckSummary(checks).State == checkFailure {
+		reasons = append(reasons, models.StaleFailingChecks)
+	}
+	return reasons
+}
+
+// hasRequestedReviewers reports whether users or teams were asked to review
+func hasRequestedReviewers(pr *github.PullRequest) bool {
+	return len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0
+}
+
+// collectStale keeps the candidates with at least one reason, failing on the first error
+// repo is 'owner/name' since account-wide reports span repositories of several owners
+func collectStale(repo string, candidates []*github.PullRequest, reasons [][]string, errs []error, now time.Time) ([]models.StalePullRequest, error) {
+	var stale []models.StalePullRequest
+	for i, pr := range candidates {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if len(reasons[i]) == 0 {
+			continue
+		}
+
+		stale = append(stale, models.StalePullRequest{
+			Repository:   repo,
+			Number:       pr.GetNumber(),
+			Title:        pr.GetTitle(),
+			Author:       pr.GetUser().GetLogin(),
+			CreatedAt:    pr.GetCreatedAt().Time,
+			UpdatedAt:    pr.GetUpdatedAt().Time,
+			DaysInactive: int(now.Sub(pr.GetUpdatedAt().Time).Hours() / 24),
+			Reasons:      reasons[i],
+			HtmlURL:      pr.GetHTMLURL(),
+		})
+	}
+	return stale, nil
+}
+
+// collectRepoResults flattens per repository results, turning failures into repository errors
+func collectRepoResults[R any](repos []*github.Repository, results [][]R, errs []error) ([]R, []models.RepoError) {
+	var all []R
+	var repoErrors []models.RepoError
+	for i, repo := range repos {
+		if errs[i] != nil {
+			repoErrors = append(repoErrors, newRepoError(repo.GetName(), errs[i]))
+			continue
+		}
+		all = append(all, results[i]...)
+	}
+	return all, repoErrors
+}
+
+// newRepoError describes a repository failure with the same message and code as a failed request
+func newRepoError(repo string, err error) models.RepoError {
+	_, body := translateGitHubError(err)
+	return models.RepoError{Repository: repo, Error: body.Error, Code: body.Code}
+}
+
+// newStaleReport groups the stale pull requests by author, the longest inactive first
+func newStaleReport(q staleQuery, stale []models.StalePullRequest, repoErrors []models.RepoError, now time.Time) models.StaleReport {
+	report := models.StaleReport{
+		OlderThan:   q.OlderThan,
+		Cutoff:      q.Cutoff,
+		GeneratedAt: now,
+		Total:       len(stale),
+		Authors:     []models.StaleAuthor{},
+		Errors:      repoErrors,
+	}
+
+	byAuthor := make(map[string][]models.StalePullRequest)
+	for _, pr := range stale {
+		byAuthor[pr.Author] = append(byAuthor[pr.Author], pr)
+	}
+	for author, prs := range byAuthor {
+		sort.SliceStable(prs, func(i, j int) bool { return prs[i].DaysInactive > prs[j].DaysInactive })
+		report.Authors = append(report.Authors, models.StaleAuthor{Author: author, PullRequests: prs})
+	}
+	sort.Slice(report.Authors, func(i, j int) bool { return report.Authors[i].Author < report.Authors[j].Author })
+
+	return report
+}
+
+// respondWithStaleReport writes the report as JSON, or as CSV with one row per pull request
+// Repositories that could not be checked are listed in the X-Failed-Repositories header of the CSV
+// Titles and logins come from users, so text cells are escaped before a spreadsheet can run them as formulas
+func respondWithStaleReport(c *gin.Context, q staleQuery, report models.StaleReport) {
+	if q.Format != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var failed []string
+	for _, repoErr := range report.Errors {
+		failed = append(failed, repoErr.Repository)
+	}
+	if len(failed) > 0 {
+		c.Header("X-Failed-Repositories", strings.Join(failed, ","))
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="stale-pull-requests.csv"`)
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"author", "repository", "number", "title", "days_inactive", "reasons", "created_at", "updated_at", "html_url"})
+	for _, author := range report.Authors {
+		for _, pr := range author.PullRequests {
+			w.Write([]string{
+				csvCell(pr.Author),
+				csvCell(pr.Repository),
+				strconv.Itoa(pr.Number),
+				csvCell(pr.Title),
+				strconv.Itoa(pr.DaysInactive),
+				strings.Join(pr.Reasons, ";"),
+				pr.CreatedAt.Format(time.RFC3339),
+				pr.UpdatedAt.Format(time.RFC3339),
+				csvCell(pr.HtmlURL),
+			})
+		}
+	}
+	w.Flush()
+}
+
+// csvCell prefixes values a spreadsheet would read as a formula with a quote, so they are shown as text
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,217 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github-api-service/internal/api/handlers"
+	"github-api-service/internal/models"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStalePullRequests(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	newPRs := func() []*github.PullRequest {
+		inactive := testPullRequest(1, "open", "alice", "inactive", daysAgo(30))
+		inactive.UpdatedAt = &github.Timestamp{Time: daysAgo(20)}
+
+		failing := testPullRequest(2, "open", "bob", "failing", daysAgo(30))
+		failing.UpdatedAt = &github.Timestamp{Time: daysAgo(1)}
+		failing.RequestedReviewers = []*github.User{{Login: github.Ptr("carol")}}
+
+		healthy := testPullRequest(3, "open", "alice", "healthy", daysAgo(20))
+		healthy.UpdatedAt = &github.Timestamp{Time: daysAgo(1)}
+
+		recent := testPullRequest(4, "open", "alice", "recent", daysAgo(2))
+
+		other := testPullRequest(5, "open", "bob", "other", daysAgo(40))
+		other.Base.Repo = &github.Repository{Name: github.Ptr("other-repo")}
+
+		prs := []*github.PullRequest{inactive, failing, healthy, recent, other}
+		for _, pr := range prs {
+			pr.Head.SHA = github.Ptr(fmt.Sprintf("sha-%d", pr.GetNumber()))
+		}
+		return prs
+	}
+
+	// newFakeClient serves the open pull requests of 'test-repo' and 'other-repo'
+	// PR #3 is approved by carol, the head of PR #2 fails its build and the head of PR #3 passes it
+	newFakeClient := func(t *testing.T, prs []*github.PullRequest) (*fakeGitHub, *handlers.Application) {
+		reviews := map[string][]*github.PullRequestReview{
+			"3": {{User: &github.User{Login: github.Ptr("carol")}, State: github.Ptr("APPROVED")}},
+		}
+		statuses := map[string][]*github.RepoStatus{
+			"sha-2": {{Context: github.Ptr("ci/build"), State: github.Ptr("failure")}},
+			"sha-3": {{Context: github.Ptr("ci/build"), State: github.Ptr("success")}},
+		}
+
+		fake, app := newFakeGitHub(t)
+		for _, repo := range []string{"test-repo", "other-repo"} {
+			var open []*github.PullRequest
+			for _, pr := range prs {
+				if pr.GetBase().GetRepo().GetName() == repo {
+					open = append(open, pr)
+				}
+			}
+
+			fake.handle("GET /repos/owner/"+repo+"/pulls", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "open", r.URL.Query().Get("state"), "Only open PRs should be listed")
+				writeJSON(w, http.StatusOK, open)
+			})
+			fake.handle("GET /repos/owner/"+repo+"/pulls/{number}/reviews", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, append([]*github.PullRequestReview{}, reviews[r.PathValue("number")]...))
+			})
+			fake.handle("GET /repos/owner/"+repo+"/commits/{sha}/status", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, github.CombinedStatus{Statuses: statuses[r.PathValue("sha")]})
+			})
+			fake.reply("GET /repos/owner/"+repo+"/commits/{sha}/check-runs", http.StatusOK, github.ListCheckRunsResults{})
+		}
+		return fake, app
+	}
+
+	staleReport := func(t *testing.T, app *handlers.Application, url string) models.StaleReport {
+		w := serveJSON(t, app, "GET", url, "")
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+		var report models.StaleReport
+		err := json.Unmarshal(w.Body.Bytes(), &report)
+		assert.NoError(t, err, errJSONUnmarshal)
+		return report
+	}
+
+	t.Run("Stale pull requests of a repository", func(t *testing.T) {
+		fake, app := newFakeClient(t, newPRs())
+
+		report := staleReport(t, app, "/repositories/test-repo/pull-requests/stale")
+
+		assert.Equal(t, "14d", report.OlderThan, "Default age should be 14 days")
+		assert.Equal(t, 2, report.Total, "There should be 2 stale PRs")
+		assert.Len(t, report.Authors, 2, "PRs should be grouped by 2 authors")
+
+		alice := report.Authors[0]
+		assert.Equal(t, "alice", alice.Author, "Authors should be sorted")
+		assert.Equal(t, 1, alice.PullRequests[0].Number, "alice's inactive PR should be listed")
+		assert.Equal(t, "owner/test-repo", alice.PullRequests[0].Repository, "Repository should name its owner")
+		assert.Equal(t, []string{models.StaleInactive, models.StaleNoReviewers}, alice.PullRequests[0].Reasons, "Reasons should match")
+		assert.Equal(t, 20, alice.PullRequests[0].DaysInactive, "Days inactive should match")
+
+		bob := report.Authors[1]
+		assert.Equal(t, 2, bob.PullRequests[0].Number, "bob's failing PR should be listed")
+		assert.Equal(t, []string{models.StaleFailingChecks}, bob.PullRequests[0].Reasons, "Reasons should match")
+
+		assert.False(t, fake.called("GET /repos/owner/test-repo/pulls/2/reviews"), "Reviews should not be fetched when reviewers are requested")
+		assert.False(t, fake.called("GET /repos/owner/test-repo/commits/sha-4/status"), "Checks should not be fetched for recent PRs")
+	})
+
+	t.Run("Custom age", func(t *testing.T) {
+		_, app := newFakeClient(t, newPRs())
+
+		report := staleReport(t, app, "/repositories/test-repo/pull-requests/stale?older_than=5w")
+
+		assert.Equal(t, 0, report.Total, "No PR is older than 5 weeks")
+	})
+
+	t.Run("Stale pull requests across the account", func(t *testing.T) {
+		fake, app := newFakeClient(t, newPRs())
+		fake.reply("GET /user/repos", http.StatusOK, []*github.Repository{
+			{Name: github.Ptr("test-repo")},
+			{Name: github.Ptr("other-repo")},
+			{Name: github.Ptr("broken-repo")},
+		})
+		fake.reply("GET /repos/owner/broken-repo/pulls", http.StatusServiceUnavailable, map[string]string{"message": "Service Unavailable"})
+
+		report := staleReport(t, app, "/pull-requests/stale")
+
+		assert.Equal(t, 3, report.Total, "There should be 3 stale PRs")
+		assert.Len(t, report.Authors[1].PullRequests, 2, "bob should have 2 stale PRs")
+		assert.Equal(t, "owner/other-repo", report.Authors[1].PullRequests[0].Repository, "Longest inactive PR should come first")
+		assert.Len(t, report.Errors, 1, "Failing repository should be reported")
+		assert.Equal(t, "broken-repo", report.Errors[0].Repository, "Failing repository should be named")
+		assert.Equal(t, models.ErrCodeUpstreamError, report.Errors[0].Code, "Failure should carry its error code")
+	})
+
+	t.Run("CSV output", func(t *testing.T) {
+		_, app := newFakeClient(t, newPRs())
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/stale?format=csv", "")
+
+		assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"), "Content type should be CSV")
+
+		rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		assert.NoError(t, err, "CSV should be valid")
+		assert.Len(t, rows, 3, "There should be a header and 2 rows")
+		assert.Equal(t, "author", rows[0][0], "First row should be the header")
+		assert.Equal(t, []string{"alice", "owner/test-repo", "1"}, rows[1][:3], "First row should be alice's PR")
+		assert.Equal(t, "inactive;no_reviewers", rows[1][5], "Reasons should be joined")
+	})
+
+	t.Run("CSV cells are never formulas", func(t *testing.T) {
+		prs := newPRs()
+		for i, title := range []string{"=HYPERLINK(\"https://evil.example\")", "+1 fix", "-2 lines", "@SUM(A1)"} {
+			prs[i].Title = github.Ptr(title)
+		}
+		_, app := newFakeClient(t, prs)
+
+		w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/stale?format=csv&older_than=1d", "")
+
+		rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		assert.NoError(t, err, "CSV should be valid")
+
+		var titles []string
+		for _, row := range rows[1:] {
+			titles = append(titles, row[3])
+		}
+		assert.ElementsMatch(t, []string{"'=HYPERLINK(\"https://evil.example\")", "'+1 fix", "'-2 lines", "'@SUM(A1)"}, titles, "Formulas should be escaped")
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"older_than=soon", "older_than=0d", "format=xml"} {
+			// Nothing is registered, invalid parameters must not reach GitHub
+			_, app := newFakeGitHub(t)
+
+			w := serveJSON(t, app, "GET", "/repositories/test-repo/pull-requests/stale?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, "Code should be 400 BadRequest for "+query)
+		}
+	})
+}
+
+func TestAccountStalePullRequestsOfMemberRepositories(t *testing.T) {
+	fake, app := newFakeGitHub(t)
+
+	pr := testPullRequest(1, "open", "alice", "feature", time.Now().AddDate(0, 0, -30))
+	pr.Head.SHA = github.Ptr("abc123")
+	pr.RequestedReviewers = []*github.User{{Login: github.Ptr("bob")}}
+
+	// 'shared' belongs to another account the token owner is a member of
+	fake.reply("GET /user/repos", http.StatusOK, []*github.Repository{
+		{Name: github.Ptr("api"), Owner: &github.User{Login: github.Ptr("owner")}},
+		{Name: github.Ptr("shared"), Owner: &github.User{Login: github.Ptr("other")}},
+	})
+	fake.reply("GET /repos/owner/api/pulls", http.StatusOK, []*github.PullRequest{})
+	fake.reply("GET /repos/other/shared/pulls", http.StatusOK, []*github.PullRequest{pr})
+	fake.reply("GET /repos/other/shared/commits/abc123/status", http.StatusOK, &github.CombinedStatus{})
+	fake.reply("GET /repos/other/shared/commits/abc123/check-runs", http.StatusOK, &github.ListCheckRunsResults{})
+
+	w := serveJSON(t, app, "GET", "/pull-requests/stale?type=all", "")
+
+	assert.Equal(t, http.StatusOK, w.Code, "Code should be 200 OK")
+
+	var response models.StaleReport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err, errJSONUnmarshal)
+
+	assert.Empty(t, response.Errors, "Member repositories should be read under their owner")
+	assert.Equal(t, 1, response.Total, "The stale pull request of the member repository should be reported")
+	assert.Equal(t, "other/shared", response.Authors[0].PullRequests[0].Repository, "Repository should name its owner")
+	assert.False(t, fake.called("GET /repos/owner/shared/pulls"), "shared should not be read as the configured owner's")
+}
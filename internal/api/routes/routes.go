@@ -1,13 +1,54 @@
 package routes
 
 import (
-    "github.com/gin-gonic/gin"
-    "github-api-service/internal/api/handlers"
+	"github-api-service/internal/api/handlers"
+	"github.com/gin-gonic/gin"
 )
 
 func SetupRoutes(r *gin.Engine, client handlers.Client) {
-    r.POST("/repositories",  client.App.CreateRepository)
-	r.GET("/repositories/:repo/pull-requests", client.App.ListOpenPullRequests)
-    r.GET("/repositories", client.App.ListRepositories)
-    r.DELETE("/repositories/:repo", client.App.DeleteRepository)
-}
\ No newline at end of file
+	// Branch names may hold slashes, which clients send encoded as %2F
+	r.UseRawPath = true
+
+	r.POST("/repositories", client.App.CreateRepository)
+	r.POST("/repositories/from-template", client.App.CreateRepositoryFromTemplate)
+	r.GET("/repositories/:repo/pull-requests", client.App.ListPullRequests)
+	r.POST("/repositories/:repo/pull-requests", client.App.CreatePullRequest)
+	r.GET("/repositories/:repo/pull-requests/stale", client.App.ListStalePullRequests)
+	r.POST("/repositories/:repo/pull-requests/close", client.App.ClosePullRequests)
+	r.GET("/repositories/:repo/pull-requests/:number", client.App.GetPullRequest)
+	r.PATCH("/repositories/:repo/pull-requests/:number", client.App.UpdatePullRequest)
+	r.GET("/repositories/:repo/pull-requests/:number/diff", client.App.GetPullRequestDiff)
+	r.GET("/repositories/:repo/pull-requests/:number/patch", client.App.GetPullRequestPatch)
+	r.PUT("/repositories/:repo/pull-requests/:number/merge", client.App.MergePullRequest)
+	r.GET("/repositories/:repo/pull-requests/:number/reviews", client.App.ListReviews)
+	r.POST("/repositories/:repo/pull-requests/:number/reviews", client.App.CreateReview)
+	r.PUT("/repositories/:repo/pull-requests/:number/reviews/:review_id/dismissals", client.App.DismissReview)
+	r.GET("/repositories/:repo/pull-requests/:number/comments", client.App.ListReviewComments)
+	r.POST("/repositories/:repo/pull-requests/:number/requested-reviewers", client.App.RequestReviewers)
+	r.DELETE("/repositories/:repo/pull-requests/:number/requested-reviewers", client.App.RemoveReviewers)
+	r.GET("/repositories", client.App.ListRepositories)
+	r.GET("/repositories/:repo", client.App.GetRepository)
+	r.DELETE("/repositories/:repo", client.App.DeleteRepository)
+	r.PATCH("/repositories/:repo", client.App.UpdateRepository)
+	r.POST("/repositories/:repo/archive", client.App.ArchiveRepository)
+	r.POST("/repositories/:repo/unarchive", client.App.UnarchiveRepository)
+	r.GET("/repositories/:repo/branches", client.App.ListBranches)
+	r.POST("/repositories/:repo/branches", client.App.CreateBranch)
+	r.POST("/repositories/:repo/branches/prune", client.App.PruneBranches)
+	r.GET("/repositories/:repo/branches/:branch", client.App.GetBranch)
+	r.DELETE("/repositories/:repo/branches/:branch", client.App.DeleteBranch)
+	r.POST("/repositories/:repo/branches/:branch/rename", client.App.RenameBranch)
+	r.GET("/repositories/:repo/branches/:branch/protection", client.App.GetBranchProtection)
+	r.PUT("/repositories/:repo/branches/:branch/protection", client.App.UpdateBranchProtection)
+	r.GET("/repositories/:repo/contents/*path", client.App.GetContents)
+	r.PUT("/repositories/:repo/contents/*path", client.App.UpdateFile)
+	r.DELETE("/repositories/:repo/contents/*path", client.App.DeleteFile)
+	r.POST("/repositories/:repo/commits", client.App.CreateCommit)
+	r.GET("/repositories/:repo/metrics/pull-requests", client.App.GetPullRequestMetrics)
+	r.GET("/pull-requests", client.App.ListAccountPullRequests)
+	r.GET("/pull-requests/stale", client.App.ListAccountStalePullRequests)
+	r.GET("/compliance", client.App.GetCompliance)
+	r.POST("/compliance/remediate", client.App.RemediateCompliance)
+	r.GET("/backups", client.App.ListBackups)
+	r.POST("/backups/:id/restore", client.App.RestoreBackup)
+}
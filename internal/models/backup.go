@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Backup describes a repository saved before deletion
+type Backup struct {
+	ID            string     `json:"id"`
+	Repository    string     `json:"repository"`
+	Owner         string     `json:"owner"`
+	Description   string     `json:"description"`
+	Private       bool       `json:"private"`
+	DefaultBranch string     `json:"default_branch"`
+	HasArchive    bool       `json:"has_archive"` // False when the repository had no commits
+	Issues        int        `json:"issues"`
+	PullRequests  int        `json:"pull_requests"`
+	Releases      int        `json:"releases"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RestoredAt    *time.Time `json:"restored_at,omitempty"`
+}
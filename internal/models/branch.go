@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// BranchResponse is a branch with its last commit
+type BranchResponse struct {
+	Name      string       `json:"name"`
+	Protected bool         `json:"protected"`
+	Default   bool         `json:"default"`
+	Commit    BranchCommit `json:"commit"`
+}
+
+// BranchCommit is the last commit of a branch, branch listings only hold its SHA unless '?details=true' is given
+type BranchCommit struct {
+	SHA     string     `json:"sha"`
+	Message string     `json:"message,omitempty"`
+	Author  string     `json:"author,omitempty"`
+	Date    *time.Time `json:"date,omitempty"` // Committer date, when the branch last moved
+}
+
+// BranchRequest creates a branch from a branch, a tag or a commit SHA
+type BranchRequest struct {
+	Name string `json:"name" binding:"required"`
+	From string `json:"from"` // Defaults to the default branch
+}
+
+type BranchRenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// DeleteBranchResponse keeps the last commit of the deleted branch so it can be recreated
+type DeleteBranchResponse struct {
+	Message string `json:"message"`
+	Branch  string `json:"branch"`
+	SHA     string `json:"sha"`
+}
+
+// BranchPruneRequest deletes the branches merged into the default branch whose last commit is older than OlderThan
+type BranchPruneRequest struct {
+	OlderThan string `json:"older_than" binding:"required"` // Such as 30d, 4w or 720h
+	DryRun    bool   `json:"dry_run"`                       // Only list the branches that would be deleted
+}
+
+// BranchPruneResponse lists the branches deleted, or that would be deleted on a dry run
+// Failed holds the branches that could not be checked or deleted
+type BranchPruneResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Cutoff  time.Time     `json:"cutoff"`
+	Deleted []string      `json:"deleted"`
+	Failed  []BranchError `json:"failed,omitempty"`
+}
+
+// BranchError describes a branch an operation failed on
+type BranchError struct {
+	Branch string `json:"branch"`
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+}
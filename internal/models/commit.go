@@ -0,0 +1,29 @@
+package models
+
+// CommitRequest writes several files in a single commit on top of Parent, then fast-forwards Branch to it
+type CommitRequest struct {
+	Branch  string        `json:"branch" binding:"required"`
+	Parent  string        `json:"parent" binding:"required"` // Full SHA the branch must still point to, the commit is refused when it moved
+	Message string        `json:"message" binding:"required"`
+	Author  *CommitAuthor `json:"author"` // Defaults to the token owner
+	Files   []CommitFile  `json:"files" binding:"required,min=1,dive"`
+}
+
+// CommitFile adds or replaces a file, or deletes it with the 'delete' action
+type CommitFile struct {
+	Path     string `json:"path" binding:"required"`
+	Action   string `json:"action" binding:"omitempty,oneof=write delete"` // Defaults to write
+	Content  string `json:"content"`
+	Encoding string `json:"encoding" binding:"omitempty,oneof=utf-8 base64"`     // Defaults to utf-8, base64 for binary files
+	Mode     string `json:"mode" binding:"omitempty,oneof=100644 100755 120000"` // Defaults to 100644, a regular file
+}
+
+// CommitResponse is the commit created and the branch moved to it
+type CommitResponse struct {
+	SHA     string `json:"sha"`
+	Tree    string `json:"tree"`
+	Parent  string `json:"parent"`
+	Branch  string `json:"branch"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Compliance rules a repository can violate
+const (
+	RulePrivate                 = "private"
+	RuleDescription             = "description"
+	RuleLicense                 = "license"
+	RuleDefaultBranchProtection = "default_branch_protection"
+	RuleNoDirectPushes          = "no_direct_pushes"
+)
+
+// ComplianceReport lists the violations of every evaluated repository
+// Errors lists the repositories that could not be evaluated, the report covers the others
+type ComplianceReport struct {
+	GeneratedAt  time.Time        `json:"generated_at"`
+	Total        int              `json:"total"`
+	Compliant    int              `json:"compliant"`
+	Repositories []RepoCompliance `json:"repositories"`
+	Errors       []RepoError      `json:"errors,omitempty"`
+}
+
+type RepoCompliance struct {
+	Repository string      `json:"repository"`
+	Compliant  bool        `json:"compliant"`
+	Violations []Violation `json:"violations"`
+}
+
+// Violation is a rule the repository breaks, Fixable ones are fixed by the remediation
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+}
+
+// RemediationRequest fixes the fixable violations, of every repository unless some are listed
+type RemediationRequest struct {
+	Repositories []string `json:"repositories"`
+	DryRun       bool     `json:"dry_run"` // Only list what would be fixed
+}
+
+// RemediationReport lists what was fixed in each repository with fixable violations
+type RemediationReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Repositories []RepoRemediation `json:"repositories"`
+	Errors       []RepoError       `json:"errors,omitempty"`
+}
+
+// RepoRemediation holds the rules fixed in a repository, or that would be fixed on a dry run
+// Remaining lists the violations left once the fixes are applied
+type RepoRemediation struct {
+	Repository string      `json:"repository"`
+	Fixed      []string    `json:"fixed"`
+	Failed     []RuleError `json:"failed,omitempty"`
+	Remaining  []Violation `json:"remaining"`
+}
+
+// RuleError describes a fix that failed
+type RuleError struct {
+	Rule  string `json:"rule"`
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// ContentResponse is a file or a directory of a repository
+// Files hold their content, as text when it is valid UTF-8 and as base64 otherwise, directories hold their entries
+type ContentResponse struct {
+	Type     string         `json:"type"` // file, dir, symlink or submodule
+	Name     string         `json:"name"`
+	Path     string         `json:"path"`
+	SHA      string         `json:"sha,omitempty"` // Blob SHA, to send back when updating or deleting the file
+	Size     int            `json:"size"`
+	Encoding string         `json:"encoding,omitempty"` // utf-8 or base64
+	Content  string         `json:"content,omitempty"`
+	Entries  []ContentEntry `json:"entries,omitempty"`
+}
+
+type ContentEntry struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	SHA  string `json:"sha"`
+	Size int    `json:"size"`
+}
+
+// FileRequest creates or updates a file in a single commit
+// SHA is the blob SHA of the file being replaced, GitHub refuses the update when the file changed since
+type FileRequest struct {
+	Message  string        `json:"message" binding:"required"`
+	Content  string        `json:"content"`
+	Encoding string        `json:"encoding" binding:"omitempty,oneof=utf-8 base64"` // Defaults to utf-8, base64 for binary files
+	Branch   string        `json:"branch"`                                          // Defaults to the default branch
+	SHA      string        `json:"sha"`                                             // Required to update an existing file
+	Author   *CommitAuthor `json:"author"`                                          // Defaults to the token owner
+}
+
+// DeleteFileRequest deletes a file in a single commit, SHA must match the blob SHA of the file
+type DeleteFileRequest struct {
+	Message string        `json:"message" binding:"required"`
+	Branch  string        `json:"branch"`
+	SHA     string        `json:"sha" binding:"required"`
+	Author  *CommitAuthor `json:"author"`
+}
+
+type CommitAuthor struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+// FileCommitResponse is the commit that wrote a file, Content is null when the file was deleted
+type FileCommitResponse struct {
+	Content *ContentResponse `json:"content"`
+	Commit  FileCommit       `json:"commit"`
+}
+
+type FileCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// Stable error codes returned in ErrorResponse.Code
+const (
+	ErrCodeBadRequest       = "bad_request"
+	ErrCodeUnauthorized     = "unauthorized"
+	ErrCodeForbidden        = "forbidden"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeConflict         = "conflict"
+	ErrCodeValidationFailed = "validation_failed"
+	ErrCodeRateLimited      = "rate_limited"
+	ErrCodeMergeBlocked     = "merge_blocked"
+	ErrCodeUpstreamError    = "upstream_error"
+	ErrCodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the body returned by every endpoint on failure
+type ErrorResponse struct {
+	Error      string         `json:"error"`
+	Code       string         `json:"code"`
+	RequestID  string         `json:"request_id,omitempty"`
+	Errors     []FieldError   `json:"errors,omitempty"`
+	RetryAfter int            `json:"retry_after,omitempty"` // Seconds to wait before retrying
+	Blockers   []MergeBlocker `json:"blockers,omitempty"`    // What prevents a pull request from being merged
+}
+
+// FieldError describes a single validation error reported by GitHub
+type FieldError struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code"`
+	Message  string `json:"message,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// PullRequestMetrics summarises the pull requests opened, merged or closed since a date
+// Durations are in hours, merge rate is merged / (merged + closed without merging)
+type PullRequestMetrics struct {
+	Repository          string             `json:"repository"`
+	Since               time.Time          `json:"since"`
+	GeneratedAt         time.Time          `json:"generated_at"`
+	Opened              int                `json:"opened"`
+	Open                int                `json:"open"`
+	Merged              int                `json:"merged"`
+	ClosedUnmerged      int                `json:"closed_unmerged"`
+	MergeRate           float64            `json:"merge_rate"`
+	TimeToFirstReview   DurationStats      `json:"time_to_first_review"`
+	TimeToMerge         DurationStats      `json:"time_to_merge"`
+	Size                SizeDistribution   `json:"size"`
+	ThroughputPerWeek   []WeeklyThroughput `json:"throughput_per_week"`
+	ThroughputPerAuthor []AuthorThroughput `json:"throughput_per_author"`
+	Truncated           bool               `json:"truncated"` // More pull requests were active than MAX_RESULTS, only the last updated were counted
+}
+
+// DurationStats describes a set of durations, Count is how many pull requests had one
+type DurationStats struct {
+	Count        int     `json:"count"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+	P90Hours     float64 `json:"p90_hours"`
+}
+
+// SizeDistribution counts pull requests by lines changed (additions + deletions)
+type SizeDistribution struct {
+	MedianLines int          `json:"median_lines"`
+	Buckets     []SizeBucket `json:"buckets"`
+}
+
+// SizeBucket holds pull requests changing at least Min lines and fewer than Max, no Max for the last bucket
+type SizeBucket struct {
+	Label string `json:"label"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
+// WeeklyThroughput counts the pull requests opened and merged during the week starting on Monday Week
+type WeeklyThroughput struct {
+	Week   string `json:"week"`
+	Opened int    `json:"opened"`
+	Merged int    `json:"merged"`
+}
+
+type AuthorThroughput struct {
+	Author           string  `json:"author"`
+	Opened           int     `json:"opened"`
+	Merged           int     `json:"merged"`
+	MedianMergeHours float64 `json:"median_merge_hours"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// BranchProtectionPolicy is the declarative protection of a branch, sent and returned as JSON or YAML
+// A nil section means the rule is turned off
+type BranchProtectionPolicy struct {
+	RequiredReviews      *RequiredReviewsPolicy      `json:"required_reviews" yaml:"required_reviews"`
+	RequiredStatusChecks *RequiredStatusChecksPolicy `json:"required_status_checks" yaml:"required_status_checks"`
+	RequireLinearHistory bool                        `json:"require_linear_history" yaml:"require_linear_history"`
+	AllowForcePushes     bool                        `json:"allow_force_pushes" yaml:"allow_force_pushes"`
+	AllowDeletions       bool                        `json:"allow_deletions" yaml:"allow_deletions"`
+	EnforceAdmins        bool                        `json:"enforce_admins" yaml:"enforce_admins"` // Apply the rules to administrators too
+}
+
+type RequiredReviewsPolicy struct {
+	RequiredApprovingReviewCount int  `json:"required_approving_review_count" yaml:"required_approving_review_count" binding:"min=0,max=6"`
+	RequireCodeOwnerReviews      bool `json:"require_code_owner_reviews" yaml:"require_code_owner_reviews"`
+	DismissStaleReviews          bool `json:"dismiss_stale_reviews" yaml:"dismiss_stale_reviews"`
+	RequireLastPushApproval      bool `json:"require_last_push_approval" yaml:"require_last_push_approval"`
+}
+
+type RequiredStatusChecksPolicy struct {
+	Strict   bool     `json:"strict" yaml:"strict"` // The branch must be up to date with the base before merging
+	Contexts []string `json:"contexts" yaml:"contexts" binding:"dive,min=1"`
+}
+
+// ProtectionChange is one difference between the protection on GitHub and the requested policy
+// Field is dotted, such as 'required_reviews.required_approving_review_count'
+type ProtectionChange struct {
+	Field     string `json:"field" yaml:"field"`
+	Current   any    `json:"current" yaml:"current"`
+	Requested any    `json:"requested" yaml:"requested"`
+}
+
+// BranchProtectionResponse is the outcome of applying a protection policy
+// Applied is false when GitHub already matched the policy or on a dry run
+type BranchProtectionResponse struct {
+	Branch  string                  `json:"branch" yaml:"branch"`
+	Applied bool                    `json:"applied" yaml:"applied"`
+	DryRun  bool                    `json:"dry_run" yaml:"dry_run"`
+	Changes []ProtectionChange      `json:"changes" yaml:"changes"`
+	Policy  *BranchProtectionPolicy `json:"policy" yaml:"policy"` // Protection in place after the call, null when there is none
+}
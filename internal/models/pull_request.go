@@ -0,0 +1,252 @@
+package models
+
+import "time"
+
+// PullRequestRequest opens a pull request merging head into base
+// Reviewers, assignees and labels are applied once the pull request exists
+type PullRequestRequest struct {
+	Title               string   `json:"title" binding:"required"`
+	Body                string   `json:"body"`
+	Head                string   `json:"head" binding:"required"` // Branch name, or 'user:branch' for forks
+	Base                string   `json:"base" binding:"required"`
+	Draft               bool     `json:"draft"`
+	MaintainerCanModify *bool    `json:"maintainer_can_modify,omitempty"`
+	Reviewers           []string `json:"reviewers,omitempty"`
+	TeamReviewers       []string `json:"team_reviewers,omitempty"` // Team slugs, organization repositories only
+	Assignees           []string `json:"assignees,omitempty"`
+	Labels              []string `json:"labels,omitempty"`
+}
+
+// PullRequestResponse is a pull request as returned by the listing endpoints
+// State is 'merged' for merged pull requests
+type PullRequestResponse struct {
+	Repository string     `json:"repository,omitempty"`
+	Title      string     `json:"title"`
+	Number     int        `json:"number"`
+	User       string     `json:"login"`
+	State      string     `json:"state"`
+	Draft      bool       `json:"draft"`
+	Base       string     `json:"base"`
+	Head       string     `json:"head"`
+	Labels     []string   `json:"labels"`
+	Assignees  []string   `json:"assignees,omitempty"`
+	Reviewers  []string   `json:"reviewers,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	MergedAt   *time.Time `json:"merged_at,omitempty"`
+	HtmlURL    string     `json:"html_url"`
+	Warnings   []string   `json:"warnings,omitempty"` // Follow-up steps that failed after the pull request was created
+}
+
+// PullRequestList is the merged list of pull requests across repositories
+// Errors lists the repositories that could not be listed, the list covers the others
+type PullRequestList struct {
+	PullRequests []PullRequestResponse `json:"pull_requests"`
+	Errors       []RepoError           `json:"errors,omitempty"`
+}
+
+// MergeRequest merges a pull request, SHA makes the merge fail if the head moved since it was reviewed
+type MergeRequest struct {
+	Method        string `json:"method" binding:"omitempty,oneof=merge squash rebase"` // Defaults to merge
+	CommitTitle   string `json:"commit_title"`
+	CommitMessage string `json:"commit_message"`
+	SHA           string `json:"sha"`
+}
+
+type MergeResponse struct {
+	Message string `json:"message"`
+	SHA     string `json:"sha"`
+	Method  string `json:"method"`
+}
+
+// PullRequestUpdateRequest changes a pull request, fields left out are not changed
+// Draft false marks a draft ready for review, true converts it back to a draft
+type PullRequestUpdateRequest struct {
+	Title *string `json:"title" binding:"omitempty,min=1"`
+	Body  *string `json:"body"`
+	Base  *string `json:"base" binding:"omitempty,min=1"`
+	State *string `json:"state" binding:"omitempty,oneof=open closed"`
+	Draft *bool   `json:"draft"`
+}
+
+// IsEmpty reports whether the update does not change anything
+func (r PullRequestUpdateRequest) IsEmpty() bool {
+	return r == PullRequestUpdateRequest{}
+}
+
+// BulkCloseRequest closes every open pull request matching the query filters
+// The comment is posted on each pull request before it is closed
+type BulkCloseRequest struct {
+	Comment string `json:"comment" binding:"required"`
+	DryRun  bool   `json:"dry_run"` // Only list the pull requests that would be closed
+}
+
+// BulkCloseResponse lists the pull requests closed, or that would be closed on a dry run
+// Failed holds the pull requests that could not be commented on or closed
+type BulkCloseResponse struct {
+	DryRun  bool               `json:"dry_run"`
+	Matched int                `json:"matched"`
+	Closed  []int              `json:"closed"`
+	Failed  []PullRequestError `json:"failed,omitempty"`
+}
+
+// PullRequestError describes a pull request an operation failed on
+type PullRequestError struct {
+	Number int    `json:"number"`
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+}
+
+// Reasons a pull request cannot be merged
+const (
+	MergeBlockerClosed              = "closed"
+	MergeBlockerAlreadyMerged       = "already_merged"
+	MergeBlockerDraft               = "draft"
+	MergeBlockerHeadChanged         = "head_changed"
+	MergeBlockerConflicts           = "conflicts"
+	MergeBlockerMergeabilityUnknown = "mergeability_unknown"
+	MergeBlockerBehind              = "behind"
+	MergeBlockerStatusCheck         = "status_check"
+	MergeBlockerChangesRequested    = "changes_requested"
+	MergeBlockerApprovals           = "approvals_required"
+)
+
+// MergeBlocker explains one reason a pull request cannot be merged
+// Context names the failing status check or the reviewer requesting changes
+type MergeBlocker struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Context string `json:"context,omitempty"`
+}
+
+// PullRequestDetail is a single pull request with its files, commits, reviews and checks
+type PullRequestDetail struct {
+	PullRequestResponse
+	Body           string              `json:"body"`
+	Mergeable      *bool               `json:"mergeable"` // null while GitHub is still computing it
+	MergeableState string              `json:"mergeable_state"`
+	Additions      int                 `json:"additions"`
+	Deletions      int                 `json:"deletions"`
+	ChangedFiles   int                 `json:"changed_files"`
+	Files          []PullRequestFile   `json:"files"`
+	Commits        []PullRequestCommit `json:"commits"`
+	Reviews        []ReviewerState     `json:"reviews"`
+	Checks         CheckSummary        `json:"checks"`
+}
+
+type PullRequestFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename,omitempty"` // Set for renamed files
+	Status           string `json:"status"`                      // added, removed, modified, renamed...
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+}
+
+type PullRequestCommit struct {
+	SHA     string    `json:"sha"`
+	Message string    `json:"message"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+}
+
+// ReviewerState is the latest review state of one reviewer
+// APPROVED, CHANGES_REQUESTED or DISMISSED, COMMENTED when the reviewer only left comments
+type ReviewerState struct {
+	Reviewer string `json:"reviewer"`
+	State    string `json:"state"`
+}
+
+// CheckSummary combines the commit statuses and check runs on the head commit
+// State is failure if any check failed, pending if any is still running, none when there are no checks
+type CheckSummary struct {
+	State   string       `json:"state"`
+	Total   int          `json:"total"`
+	Success int          `json:"success"`
+	Pending int          `json:"pending"`
+	Failure int          `json:"failure"`
+	Checks  []CheckState `json:"checks"`
+}
+
+type CheckState struct {
+	Name  string `json:"name"`
+	State string `json:"state"` // success, pending or failure
+}
+
+// DiffFile is one file of a unified diff
+// Status is added, removed, renamed or modified, binary files have no hunks
+type DiffFile struct {
+	OldPath string     `json:"old_path,omitempty"` // Empty for added files
+	NewPath string     `json:"new_path,omitempty"` // Empty for removed files
+	Status  string     `json:"status"`
+	Binary  bool       `json:"binary"`
+	Hunks   []DiffHunk `json:"hunks"`
+}
+
+// PatchCommit is one commit of a patch with the files it changes
+type PatchCommit struct {
+	SHA     string     `json:"sha"`
+	Subject string     `json:"subject"` // First line of the commit message
+	Files   []DiffFile `json:"files"`
+}
+
+// DiffHunk is a '@@ -old_start,old_lines +new_start,new_lines @@ section' block
+type DiffHunk struct {
+	Header   string     `json:"header"`
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Section  string     `json:"section,omitempty"` // Enclosing function or section, when git found one
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine is a single line of a hunk, line numbers are omitted on the side the line does not exist
+type DiffLine struct {
+	Type    string `json:"type"` // context, add or delete
+	Content string `json:"content"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Reasons a pull request shows up in the stale report
+const (
+	StaleInactive      = "inactive"
+	StaleNoReviewers   = "no_reviewers"
+	StaleFailingChecks = "failing_checks"
+)
+
+// StaleReport lists the stale pull requests grouped by author
+// Errors lists the repositories that could not be checked, the report covers the others
+type StaleReport struct {
+	OlderThan   string        `json:"older_than"`
+	Cutoff      time.Time     `json:"cutoff"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Total       int           `json:"total"`
+	Authors     []StaleAuthor `json:"authors"`
+	Errors      []RepoError   `json:"errors,omitempty"`
+}
+
+type StaleAuthor struct {
+	Author       string             `json:"author"`
+	PullRequests []StalePullRequest `json:"pull_requests"`
+}
+
+type StalePullRequest struct {
+	Repository   string    `json:"repository"` // owner/name
+	Number       int       `json:"number"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	DaysInactive int       `json:"days_inactive"`
+	Reasons      []string  `json:"reasons"`
+	HtmlURL      string    `json:"html_url"`
+}
+
+// RepoError reports a repository that failed during an account-wide operation
+type RepoError struct {
+	Repository string `json:"repository"`
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+}
@@ -3,27 +3,150 @@ package models
 import "time"
 
 type RepoRequest struct {
-    Name        string `json:"name" binding:"required"`
-    Description string `json:"description"`
-    Private     bool   `json:"private"`
+	Name                string   `json:"name" binding:"required"`
+	Description         string   `json:"description"`
+	Private             bool     `json:"private"`
+	Organization        string   `json:"organization,omitempty"` // Create under this organization instead of the authenticated user
+	Homepage            string   `json:"homepage,omitempty"`
+	Visibility          string   `json:"visibility,omitempty" binding:"omitempty,oneof=public private internal"` // Takes precedence over private
+	AutoInit            bool     `json:"auto_init,omitempty"`
+	GitignoreTemplate   string   `json:"gitignore_template,omitempty"`
+	LicenseTemplate     string   `json:"license_template,omitempty"`
+	DefaultBranch       string   `json:"default_branch,omitempty"` // Requires auto_init
+	Topics              []string `json:"topics,omitempty"`
+	AllowSquashMerge    *bool    `json:"allow_squash_merge,omitempty"`
+	AllowRebaseMerge    *bool    `json:"allow_rebase_merge,omitempty"`
+	AllowMergeCommit    *bool    `json:"allow_merge_commit,omitempty"`
+	DeleteBranchOnMerge *bool    `json:"delete_branch_on_merge,omitempty"`
+	HasIssues           *bool    `json:"has_issues,omitempty"`
+	HasWiki             *bool    `json:"has_wiki,omitempty"`
+	HasProjects         *bool    `json:"has_projects,omitempty"`
+}
+
+// RepoSummary is the short form of a repository used in listings
+type RepoSummary struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+	Archived    bool      `json:"archived"`
+	Visibility  string    `json:"visibility,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Topics      []string  `json:"topics,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	PushedAt    time.Time `json:"pushed_at"`
+}
+
+// RepoDetail is the full view of a single repository
+type RepoDetail struct {
+	Name          string          `json:"name"`
+	FullName      string          `json:"full_name"`
+	Owner         string          `json:"owner"`
+	Description   string          `json:"description"`
+	Visibility    string          `json:"visibility"`
+	Private       bool            `json:"private"`
+	Archived      bool            `json:"archived"`
+	DefaultBranch string          `json:"default_branch"`
+	Languages     []LanguageShare `json:"languages"`
+	Topics        []string        `json:"topics"`
+	Stars         int             `json:"stars"`
+	Forks         int             `json:"forks"`
+	OpenIssues    int             `json:"open_issues"`
+	Size          int             `json:"size"` // In kilobytes
+	License       string          `json:"license,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PushedAt      time.Time       `json:"pushed_at"`
+	HtmlURL       string          `json:"html_url"`
+	CloneURLs     CloneURLs       `json:"clone_urls"`
+	Permissions   map[string]bool `json:"permissions,omitempty"` // What the authenticated user can do
+}
+
+// LanguageShare is the part of a repository written in a language
+type LanguageShare struct {
+	Name    string  `json:"name"`
+	Bytes   int     `json:"bytes"`
+	Percent float64 `json:"percent"`
+}
+
+type CloneURLs struct {
+	HTTPS string `json:"https"`
+	SSH   string `json:"ssh"`
+	Git   string `json:"git,omitempty"`
 }
 
 type RepoResponse struct {
-    Message     string `json:"message"`
-    Name        string `json:"name" binding:"required"`
-    Description string `json:"description"`
-    Private     bool   `json:"private"`
+	Message             string   `json:"message"`
+	Name                string   `json:"name" binding:"required"`
+	Owner               string   `json:"owner,omitempty"`
+	Description         string   `json:"description"`
+	Private             bool     `json:"private"`
+	Homepage            string   `json:"homepage,omitempty"`
+	Visibility          string   `json:"visibility,omitempty"`
+	DefaultBranch       string   `json:"default_branch,omitempty"`
+	License             string   `json:"license,omitempty"`
+	Topics              []string `json:"topics,omitempty"`
+	HtmlURL             string   `json:"html_url,omitempty"`
+	AllowSquashMerge    bool     `json:"allow_squash_merge"`
+	AllowRebaseMerge    bool     `json:"allow_rebase_merge"`
+	AllowMergeCommit    bool     `json:"allow_merge_commit"`
+	DeleteBranchOnMerge bool     `json:"delete_branch_on_merge"`
+	Archived            bool     `json:"archived"`
+	HasIssues           bool     `json:"has_issues"`
+	HasWiki             bool     `json:"has_wiki"`
+	HasProjects         bool     `json:"has_projects"`
+	Template            string   `json:"template,omitempty"` // owner/repo of the template the repository was generated from
+	Warnings            []string `json:"warnings,omitempty"` // Follow-up steps that failed after the repository was created
+}
+
+type TemplateRepoRequest struct {
+	TemplateOwner      string `json:"template_owner" binding:"required"`
+	TemplateRepo       string `json:"template_repo" binding:"required"`
+	Name               string `json:"name" binding:"required"`
+	Owner              string `json:"owner"` // Defaults to the configured owner
+	Description        string `json:"description"`
+	Private            bool   `json:"private"`
+	IncludeAllBranches bool   `json:"include_all_branches"`
+}
+
+// RepoUpdateRequest is a partial update, only the fields present in the request are changed
+type RepoUpdateRequest struct {
+	Name                *string `json:"name" binding:"omitempty,min=1"`
+	Description         *string `json:"description"`
+	Homepage            *string `json:"homepage"`
+	Visibility          *string `json:"visibility" binding:"omitempty,oneof=public private internal"`
+	DefaultBranch       *string `json:"default_branch" binding:"omitempty,min=1"`
+	AllowSquashMerge    *bool   `json:"allow_squash_merge"`
+	AllowRebaseMerge    *bool   `json:"allow_rebase_merge"`
+	AllowMergeCommit    *bool   `json:"allow_merge_commit"`
+	DeleteBranchOnMerge *bool   `json:"delete_branch_on_merge"`
+	HasIssues           *bool   `json:"has_issues"`
+	HasWiki             *bool   `json:"has_wiki"`
+	HasProjects         *bool   `json:"has_projects"`
+}
+
+// IsEmpty reports whether the update does not change anything
+func (r RepoUpdateRequest) IsEmpty() bool {
+	return r == RepoUpdateRequest{}
 }
 
 type DeleteRepoResponse struct {
-    Message  string `json:"message"`
+	Message  string `json:"message"`
+	BackupID string `json:"backup_id,omitempty"`
 }
 
-type PullRequestResponse struct {
-    Title     string    `json:"title"`
-    Number    int       `json:"number"`
-    User      string    `json:"login"`
-    CreatedAt time.Time `json:"created_at"`
-    HtmlURL   string    `json:"html_url"`
+// RepoDeleteMetadata is the repository state a delete confirmation token is bound to
+type RepoDeleteMetadata struct {
+	Stars            int       `json:"stars"`
+	PushedAt         time.Time `json:"pushed_at"`
+	OpenPullRequests int       `json:"open_pull_requests"`
 }
 
+// DeleteConfirmationResponse is returned by the first delete call, the token must be
+// sent back as '?confirm=<token>' to actually delete the repository
+type DeleteConfirmationResponse struct {
+	Message    string             `json:"message"`
+	Repository string             `json:"repository"`
+	Token      string             `json:"token"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	Metadata   RepoDeleteMetadata `json:"metadata"`
+}
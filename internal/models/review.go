@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ReviewRequest submits a review, body is required to request changes or comment
+type ReviewRequest struct {
+	Event    string                 `json:"event" binding:"required,oneof=APPROVE REQUEST_CHANGES COMMENT"`
+	Body     string                 `json:"body"`
+	CommitID string                 `json:"commit_id"` // Defaults to the head commit
+	Comments []ReviewCommentRequest `json:"comments" binding:"omitempty,dive"`
+}
+
+// ReviewCommentRequest is an inline comment on a line, or on a range of lines when start_line is set
+// Side is RIGHT for the new version of the file (default) and LEFT for the old one
+type ReviewCommentRequest struct {
+	Path      string `json:"path" binding:"required"`
+	Line      int    `json:"line" binding:"required,min=1"`
+	Side      string `json:"side" binding:"omitempty,oneof=LEFT RIGHT"`
+	StartLine int    `json:"start_line" binding:"omitempty,min=1,ltfield=Line"`
+	StartSide string `json:"start_side" binding:"omitempty,oneof=LEFT RIGHT"`
+	Body      string `json:"body" binding:"required"`
+}
+
+type ReviewResponse struct {
+	ID          int64     `json:"id"`
+	Reviewer    string    `json:"reviewer"`
+	State       string    `json:"state"`
+	Body        string    `json:"body"`
+	CommitID    string    `json:"commit_id"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	HtmlURL     string    `json:"html_url"`
+}
+
+type ReviewCommentResponse struct {
+	ID        int64     `json:"id"`
+	ReviewID  int64     `json:"review_id"`
+	InReplyTo int64     `json:"in_reply_to,omitempty"`
+	User      string    `json:"login"`
+	Path      string    `json:"path"`
+	Line      int       `json:"line"`
+	Side      string    `json:"side"`
+	StartLine int       `json:"start_line,omitempty"`
+	Body      string    `json:"body"`
+	CommitID  string    `json:"commit_id"`
+	CreatedAt time.Time `json:"created_at"`
+	HtmlURL   string    `json:"html_url"`
+}
+
+type DismissReviewRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// ReviewersRequest lists users and team slugs to request or remove as reviewers
+type ReviewersRequest struct {
+	Reviewers     []string `json:"reviewers"`
+	TeamReviewers []string `json:"team_reviewers"`
+}